@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// runOperation executes one line of an operations file. ran is false when
+// a pinned config was skipped instead of disabled.
+func runOperation(fields []string, force bool) (ran bool, err error) {
+	op := fields[0]
+	args := fields[1:]
+
+	switch op {
+	case "backup", "restore", "disable", "enable":
+		if len(args) != 1 {
+			return false, fmt.Errorf("%s takes exactly one filename", op)
+		}
+
+		// enable/disable are the friendlier names for restore/backup
+		action := op
+		if op == "disable" {
+			action = "backup"
+		} else if op == "enable" {
+			action = "restore"
+		}
+
+		if action == "backup" {
+			if ok, err := mayDisable(args[0], force, true); !ok {
+				return false, err
+			}
+		}
+
+		src, dst, err := moveConf(action, args[0])
+		if err != nil {
+			return false, err
+		}
+		fmt.Printf("Success: %s moved %s -> %s\n", confFilename(args[0]), src, dst)
+		return true, nil
+	case "reload":
+		if len(args) != 0 {
+			return false, fmt.Errorf("reload takes no arguments")
+		}
+		return true, reloadNginx()
+	default:
+		return false, fmt.Errorf("unknown operation %q", op)
+	}
+}
+
+// 8. Apply Functionality - Run a file of operations in order
+func handleApply(args []string) {
+	fs := flag.NewFlagSet("apply", flag.ExitOnError)
+	stopOnError := fs.Bool("stop-on-error", false, "stop at the first failing operation")
+	output := fs.String("output", "text", "output format: text or json (per-operation results)")
+	force := fs.Bool("force", false, "disable pinned configs too")
+	positional := parseFlags(fs, args)
+
+	if len(positional) != 1 {
+		fail(ErrUsage, "Usage: ./conf-mover apply [operations-file] [--stop-on-error] [--force] [--output=json]")
+	}
+
+	data, err := os.ReadFile(positional[0])
+	if err != nil {
+		fail(codeOf(err), "Error reading operations file: %v", err)
+	}
+
+	results := newResultCollector(*output)
+	succeeded, failed, skipped := 0, 0, 0
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		filename := ""
+		if len(fields) > 1 {
+			filename = confFilename(fields[1])
+		}
+
+		if *stopOnError && failed > 0 {
+			results.addStatus(filename, fields[0], "skipped")
+			skipped++
+			continue
+		}
+
+		fmt.Printf("==> line %d: %s\n", i+1, strings.Join(fields, " "))
+		ran, err := runOperation(fields, *force)
+		if err == nil && !ran {
+			results.addStatus(filename, fields[0], "skipped")
+			skipped++
+			continue
+		}
+		results.add(filename, fields[0], err)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			failed++
+			continue
+		}
+		succeeded++
+	}
+
+	fmt.Printf("Summary: %d succeeded, %d failed, %d skipped\n", succeeded, failed, skipped)
+	results.flush()
+	if failed > 0 {
+		exit(1)
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Finding is one problem reported by audit
+type Finding struct {
+	File    string
+	Message string
+}
+
+// auditOptions tunes which findings audit reports
+type auditOptions struct {
+	allow    map[string]bool // Files allowed to have no server_name
+	ports    map[string]bool // Ports enabled configs may listen on
+	certDays int             // Certificates expiring sooner are reported
+}
+
+// auditCheck inspects the whole inventory and returns its findings
+type auditCheck func(files []FileData, opts auditOptions) []Finding
+
+// auditChecks run in order; add new checks here
+var auditChecks = []auditCheck{
+	checkMissingServerName,
+	checkMissingListen,
+	checkNonStandardPorts,
+	checkDuplicateNames,
+	checkServerNameShadowing,
+	checkCertExpiry,
+}
+
+// checkMissingServerName flags enabled configs without a parseable
+// server_name, which usually means the directive was mangled in an edit
+func checkMissingServerName(files []FileData, opts auditOptions) []Finding {
+	var findings []Finding
+	for _, file := range files {
+		if !file.enabled() || opts.allow[file.Filename] {
+			continue
+		}
+		if len(file.ServerNames) == 0 {
+			findings = append(findings, Finding{file.Filename, fmt.Sprintf("no server_name parsed (%s)", file.ServerName)})
+		}
+	}
+	return findings
+}
+
+// checkMissingListen flags enabled configs without any listen directive.
+// They silently inherit nginx's default (*:80, or *:8000 when not root) and
+// are often snippets that were never meant to be a server block.
+func checkMissingListen(files []FileData, opts auditOptions) []Finding {
+	var findings []Finding
+	for _, file := range files {
+		if !file.enabled() || file.ParseError != "" || file.Skipped != "" {
+			continue
+		}
+		if len(file.listens) == 0 {
+			findings = append(findings, Finding{file.Filename, "no listen directive, nginx defaults will apply"})
+		}
+	}
+	return findings
+}
+
+// checkNonStandardPorts flags enabled configs listening on ports outside
+// the allowed set, typically a debug server someone forgot about
+func checkNonStandardPorts(files []FileData, opts auditOptions) []Finding {
+	var findings []Finding
+	for _, file := range files {
+		if !file.enabled() {
+			continue
+		}
+		for _, listen := range file.listens {
+			if strings.HasPrefix(listen.Address, "unix:") {
+				continue
+			}
+			port := listen.Address[strings.LastIndex(listen.Address, ":")+1:]
+			if !opts.ports[port] {
+				findings = append(findings, Finding{file.Filename, fmt.Sprintf("listens on non-standard port %s (%s)", port, listen.Address)})
+			}
+		}
+	}
+	return findings
+}
+
+// checkDuplicateNames flags server_names claimed by more than one enabled
+// config; nginx warns and serves only the first
+func checkDuplicateNames(files []FileData, opts auditOptions) []Finding {
+	duplicates := duplicateServerNames(files)
+	var names []string
+	for name := range duplicates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var findings []Finding
+	for _, name := range names {
+		owners := duplicates[name]
+		findings = append(findings, Finding{owners[0], fmt.Sprintf("server_name %s is also declared in %s", name, strings.Join(owners[1:], ", "))})
+	}
+	return findings
+}
+
+// checkCertExpiry flags certificates of enabled configs that are missing,
+// unreadable or expire within opts.certDays
+func checkCertExpiry(files []FileData, opts auditOptions) []Finding {
+	var findings []Finding
+	now := time.Now()
+	for _, check := range checkCerts(files) {
+		if check.err != nil {
+			findings = append(findings, Finding{check.file, check.err.Error()})
+			continue
+		}
+		days := int(check.cert.NotAfter.Sub(now).Hours() / 24)
+		if days < opts.certDays {
+			findings = append(findings, Finding{check.file, fmt.Sprintf("certificate for %s expires %s (%d days)",
+				strings.Join(certDomains(check.cert), ", "), check.cert.NotAfter.Format("2006-01-02"), days)})
+		}
+	}
+	return findings
+}
+
+// runAudit scans the configs and applies every audit check
+func runAudit(opts auditOptions) []Finding {
+	files, errs := scanConfigs()
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	var findings []Finding
+	for _, check := range auditChecks {
+		findings = append(findings, check(files, opts)...)
+	}
+	return findings
+}
+
+// 11. Audit Functionality - Catch configs that are probably mistakes
+func handleAudit(args []string) {
+	fs := flag.NewFlagSet("audit", flag.ExitOnError)
+	var allow stringList
+	fs.Var(&allow, "allow", "config allowed to have no server_name (repeatable or comma-separated)")
+	var ports stringList
+	fs.Var(&ports, "allowed-ports", "ports enabled configs may listen on (repeatable or comma-separated, default 80,443)")
+	follow := fs.Bool("follow", false, "keep running, re-auditing every --interval")
+	interval := fs.Duration("interval", 5*time.Minute, "time between audits with --follow")
+	changesOnly := fs.Bool("changes-only", false, "with --follow, only print when the findings change")
+	certDays := fs.Int("cert-days", 30, "report certificates expiring within this many days")
+	parseFlags(fs, args)
+
+	if *interval <= 0 {
+		fail(ErrInvalid, "Error: --interval must be positive")
+	}
+
+	if len(ports) == 0 {
+		ports = stringList{"80", "443"}
+	}
+
+	opts := auditOptions{allow: map[string]bool{}, ports: map[string]bool{}, certDays: *certDays}
+	for _, name := range allow {
+		opts.allow[confFilename(name)] = true
+	}
+	for _, port := range ports {
+		opts.ports[port] = true
+	}
+
+	if *follow {
+		followAudit(opts, *interval, *changesOnly)
+		return
+	}
+
+	findings := runAudit(opts)
+	printFindings(findings)
+	if len(findings) > 0 {
+		exit(1)
+	}
+}
+
+// printFindings reports audit findings, or that there were none
+func printFindings(findings []Finding) {
+	if len(findings) == 0 {
+		fmt.Println("✓ No problems found")
+		return
+	}
+
+	for _, finding := range findings {
+		fmt.Printf("❌ %s: %s\n", finding.File, finding.Message)
+	}
+	fmt.Printf("%d problem(s) found\n", len(findings))
+}
+
+// followAudit re-runs the audit every interval until SIGINT or SIGTERM,
+// so it can run as a long-lived service. With changesOnly a run is only
+// printed when its findings differ from the previous one.
+func followAudit(opts auditOptions, interval time.Duration, changesOnly bool) {
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var previous []Finding
+	for first := true; ; first = false {
+		findings := runAudit(opts)
+		if first || !changesOnly || !reflect.DeepEqual(findings, previous) {
+			fmt.Printf("==> %s\n", time.Now().Format(time.RFC3339))
+			printFindings(findings)
+		}
+		previous = findings
+
+		select {
+		case <-ticker.C:
+		case sig := <-stop:
+			fmt.Printf("Received %s, stopping\n", sig)
+			return
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// auditedCommands are recorded in the audit log when they run: everything
+// that writes to NginxDir plus the other commands that change what nginx
+// or conf-mover's own bookkeeping looks like
+var auditedCommands = map[string]bool{
+	"reload": true, "pin": true, "unpin": true,
+	"backup-changed": true, "backup-dedupe": true, "tag": true,
+	"unbundle": true,
+}
+
+// audited reports whether an invocation belongs in the audit log. lint
+// only changes configs with --fix.
+func audited(command string, args []string) bool {
+	if command == "lint" {
+		for _, arg := range args {
+			name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+			if !strings.HasPrefix(arg, "-") || name != "fix" {
+				continue
+			}
+			fix, err := strconv.ParseBool(value)
+			return !hasValue || (err == nil && fix)
+		}
+		return false
+	}
+	return auditedCommands[command] || writesNginxDir[command]
+}
+
+// recordAction arranges for one line describing this invocation to be
+// appended to the audit log when it exits, with its outcome: "ok", or the
+// exit status of a failure. Usage errors from flag parsing exit before
+// that and aren't recorded. The log is rotated first if it has grown past
+// AuditMaxSize; one that can't be written is reported but never changes
+// how the command ends.
+func recordAction(command string, args []string) {
+	if cfg.AuditLog == "" || simulate || !audited(command, args) {
+		return
+	}
+	atExit = append(atExit, func() { writeAuditEntry(command, args) })
+}
+
+// writeAuditEntry appends the audit log line for this invocation
+func writeAuditEntry(command string, args []string) {
+	outcome := "ok"
+	if exitStatus != 0 {
+		outcome = fmt.Sprintf("exit %d", exitStatus)
+	}
+
+	name := "unknown"
+	if current, err := user.Current(); err == nil {
+		name = current.Username
+	}
+	if sudoUser := os.Getenv("SUDO_USER"); sudoUser != "" {
+		name = sudoUser + " (sudo)"
+	}
+	entry := fmt.Sprintf("%s %s [%s] %s\n", time.Now().Format(time.RFC3339), name, outcome, strings.Join(append([]string{command}, args...), " "))
+
+	if err := rotateAuditLog(int64(len(entry))); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: rotating audit log: %v\n", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cfg.AuditLog), 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: writing audit log: %v\n", err)
+		return
+	}
+	f, err := os.OpenFile(cfg.AuditLog, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err == nil {
+		_, err = f.WriteString(entry)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: writing audit log: %v\n", err)
+	}
+}
+
+// rotateAuditLog shifts audit.log to audit.log.1, audit.log.1 to
+// audit.log.2 and so on, keeping AuditKeep generations, when writing
+// another incoming bytes would take the log past AuditMaxSize
+func rotateAuditLog(incoming int64) error {
+	if cfg.AuditMaxSize <= 0 {
+		return nil
+	}
+	info, err := os.Stat(cfg.AuditLog)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Size()+incoming <= cfg.AuditMaxSize {
+		return nil
+	}
+
+	if cfg.AuditKeep < 1 {
+		return os.Truncate(cfg.AuditLog, 0)
+	}
+
+	generation := func(n int) string { return fmt.Sprintf("%s.%d", cfg.AuditLog, n) }
+	if err := os.Remove(generation(cfg.AuditKeep)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for n := cfg.AuditKeep - 1; n >= 1; n-- {
+		if err := os.Rename(generation(n), generation(n+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.Rename(cfg.AuditLog, generation(1))
+}
+
+// 40. Audit Log Functionality - Who changed what, and when
+func handleAuditLog(args []string) {
+	fs := flag.NewFlagSet("audit-log", flag.ExitOnError)
+	lines := fs.Int("n", 20, "number of entries to show")
+	positional := parseFlags(fs, args)
+
+	if len(positional) != 1 || positional[0] != "show" {
+		fail(ErrUsage, "Usage: ./conf-mover audit-log show [-n 20]")
+	}
+
+	content, err := os.ReadFile(cfg.AuditLog)
+	if os.IsNotExist(err) {
+		fmt.Println("No actions recorded yet")
+		return
+	}
+	if err != nil {
+		fail(codeOf(err), "Error reading audit log: %v", err)
+	}
+
+	entries := strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+	if *lines > 0 && len(entries) > *lines {
+		entries = entries[len(entries)-*lines:]
+	}
+	for _, entry := range entries {
+		fmt.Println(entry)
+	}
+}
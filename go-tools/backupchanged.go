@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// 34. Backup Changed Functionality - Version only the configs that changed
+func handleBackupChanged() {
+	paths, err := confPaths(cfg.NginxDir)
+	if err != nil {
+		fail(codeOf(err), "Error reading nginx directory: %v", err)
+	}
+
+	saved, skipped, failed := 0, 0, 0
+	for _, path := range paths {
+		filename := confFilename(path)
+
+		hash, err := hashFile(path)
+		if err != nil {
+			fmt.Printf("❌ %s: %v\n", filename, err)
+			failed++
+			continue
+		}
+
+		latest, err := latestVersion(filename)
+		if err != nil {
+			fmt.Printf("❌ %s: %v\n", filename, err)
+			failed++
+			continue
+		}
+		if latest != "" {
+			if latestHash, err := hashFile(latest); err == nil && latestHash == hash {
+				fmt.Printf("  unchanged  %s\n", filename)
+				skipped++
+				continue
+			}
+		}
+
+		content, err := os.ReadFile(path)
+		if err == nil {
+			_, err = saveVersion(filename, content)
+		}
+		if err != nil {
+			fmt.Printf("❌ %s: %v\n", filename, err)
+			failed++
+			continue
+		}
+		fmt.Printf("✓ backed up %s\n", filename)
+		saved++
+	}
+
+	fmt.Printf("Summary: %d backed up, %d unchanged, %d failed\n", saved, skipped, failed)
+	if failed > 0 {
+		exit(1)
+	}
+}
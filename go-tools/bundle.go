@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Bundle is every config with its status, in one text-diffable file
+type Bundle struct {
+	Created time.Time     `json:"created"`
+	Configs []BundledConf `json:"configs"`
+}
+
+// BundledConf is one config inside a Bundle
+type BundledConf struct {
+	Filename string `json:"filename"`
+	Enabled  bool   `json:"enabled"`
+	Content  string `json:"content"`
+}
+
+// 44. Bundle Functionality - Export every config into one JSON file
+func handleBundle(args []string) {
+	fs := flag.NewFlagSet("bundle", flag.ExitOnError)
+	output := fs.String("output", "", "file to write the bundle to (- for stdout)")
+	parseFlags(fs, args)
+
+	if *output == "" {
+		fail(ErrUsage, "Usage: ./conf-mover bundle --output=bundle.json")
+	}
+
+	bundle := Bundle{Created: time.Now().UTC(), Configs: []BundledConf{}}
+	for _, source := range []string{cfg.NginxDir, cfg.BackupDir} {
+		paths, err := confPaths(source)
+		if err != nil {
+			fail(codeOf(err), "Error reading %s: %v", source, err)
+		}
+		for _, path := range paths {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				fail(codeOf(err), "Error reading config: %v", err)
+			}
+			bundle.Configs = append(bundle.Configs, BundledConf{
+				Filename: confFilename(path),
+				Enabled:  source == cfg.NginxDir,
+				Content:  string(content),
+			})
+		}
+	}
+
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		fail(ErrIO, "Error generating JSON")
+	}
+	data = append(data, '\n')
+
+	if *output == "-" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := writeFileAtomic(*output, data); err != nil {
+		fail(codeOf(err), "Error writing %s: %v", *output, err)
+	}
+	fmt.Printf("✓ Bundled %d config(s) into %s\n", len(bundle.Configs), *output)
+}
+
+// 45. Unbundle Functionality - Recreate the configs from a bundle
+func handleUnbundle(args []string) {
+	fs := flag.NewFlagSet("unbundle", flag.ExitOnError)
+	to := fs.String("to", "", "write enabled configs to DIR/enabled and disabled ones to DIR/disabled instead of NGINX_DIR and BACKUP_DIR")
+	force := fs.Bool("force", false, "overwrite configs that already exist")
+	positional := parseFlags(fs, args)
+
+	if len(positional) != 1 {
+		fail(ErrUsage, "Usage: ./conf-mover unbundle [bundle.json] [--to=DIR] [--force]")
+	}
+
+	data, err := os.ReadFile(positional[0])
+	if err != nil {
+		fail(codeOf(err), "Error reading bundle: %v", err)
+	}
+	var bundle Bundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		fail(ErrInvalid, "Error: %s is not a bundle: %v", positional[0], err)
+	}
+
+	enabledDir, disabledDir := cfg.NginxDir, cfg.BackupDir
+	if *to != "" {
+		enabledDir, disabledDir = filepath.Join(*to, "enabled"), filepath.Join(*to, "disabled")
+	} else if isGlobPattern(cfg.NginxDir) {
+		fail(ErrInvalid, "Error: NGINX_DIR is a glob pattern (%s); use --to to pick a directory", cfg.NginxDir)
+	}
+
+	// Check everything first so a conflict doesn't leave half a bundle behind
+	for _, conf := range bundle.Configs {
+		if err := validateConfName(conf.Filename); err != nil {
+			fail(codeOf(err), "Error: %v", err)
+		}
+		if *force {
+			continue
+		}
+		for _, dir := range []string{enabledDir, disabledDir} {
+			path := filepath.Join(dir, conf.Filename)
+			if _, err := os.Lstat(path); err == nil {
+				fail(ErrDstExists, "Error: %s already exists (use --force to overwrite)", path)
+			}
+		}
+	}
+
+	for _, dir := range []string{enabledDir, disabledDir} {
+		if err := ensureDir(dir); err != nil {
+			fail(codeOf(err), "Error creating directory: %v", err)
+		}
+	}
+
+	for _, conf := range bundle.Configs {
+		dir := disabledDir
+		if conf.Enabled {
+			dir = enabledDir
+		}
+		path := filepath.Join(dir, conf.Filename)
+		if err := writeFileAtomic(path, []byte(conf.Content)); err != nil {
+			fail(codeOf(err), "Error writing %s: %v", path, err)
+		}
+		fmt.Printf("✓ %s\n", path)
+	}
+	fmt.Printf("Summary: %d config(s) restored from a bundle made %s\n", len(bundle.Configs), bundle.Created.Format(time.RFC3339))
+}
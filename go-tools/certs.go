@@ -0,0 +1,156 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// resolveNginxPath resolves a path from a config the way nginx does:
+// relative paths are taken from the nginx prefix, which we assume is the
+// parent of NginxDir (/etc/nginx for /etc/nginx/conf.d)
+func resolveNginxPath(path string) string {
+	return resolveFrom(filepath.Dir(cfg.NginxDir), path)
+}
+
+// resolveFrom resolves a path from a config against an explicit nginx prefix
+func resolveFrom(prefix, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(prefix, path)
+}
+
+// errDynamicCert is returned for certificate paths built from variables,
+// which can only be resolved by nginx at request time
+var errDynamicCert = errors.New("certificate path is chosen at runtime")
+
+// loadCert parses the first (leaf) certificate in a PEM file
+func loadCert(path string) (*x509.Certificate, error) {
+	if strings.Contains(path, "$") {
+		return nil, errDynamicCert
+	}
+
+	data, err := os.ReadFile(resolveNginxPath(path))
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("no certificate found in %s", path)
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", path, err)
+		}
+		return cert, nil
+	}
+}
+
+// certExpiry returns the NotAfter time of the certificate in a PEM file
+func certExpiry(path string) (time.Time, error) {
+	cert, err := loadCert(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return cert.NotAfter, nil
+}
+
+// certDomains lists the names a certificate covers
+func certDomains(cert *x509.Certificate) []string {
+	if len(cert.DNSNames) > 0 {
+		return cert.DNSNames
+	}
+	return []string{cert.Subject.CommonName}
+}
+
+// 19. Certs Functionality - Expiry check suitable for cron or nagios
+func handleCerts(args []string) {
+	fs := flag.NewFlagSet("certs", flag.ExitOnError)
+	warnDays := fs.Int("warn-days", 30, "exit 1 if a certificate expires within this many days")
+	critDays := fs.Int("crit-days", 7, "exit 2 if a certificate expires within this many days")
+	parseFlags(fs, args)
+
+	files, errs := scanConfigs()
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	now := time.Now()
+	code := 0
+	checks := checkCerts(files)
+	for _, check := range checks {
+		if check.err != nil {
+			// nginx won't start without it, so this is as bad as expired
+			fmt.Printf("CRITICAL %s: %v\n", check.file, check.err)
+			code = 2
+			continue
+		}
+
+		cert := check.cert
+		days := int(cert.NotAfter.Sub(now).Hours() / 24)
+		status := "OK"
+		switch {
+		case days < *critDays:
+			status = "CRITICAL"
+			code = 2
+		case days < *warnDays:
+			status = "WARNING"
+			if code < 1 {
+				code = 1
+			}
+		}
+		fmt.Printf("%s %s: %s expires %s (%d days)\n", status, check.file,
+			strings.Join(certDomains(cert), ", "), cert.NotAfter.Format("2006-01-02"), days)
+	}
+
+	if len(checks) == 0 {
+		fmt.Println("No ssl_certificate directives found")
+	}
+	exit(code)
+}
+
+// certCheck is one certificate used by an enabled config
+type certCheck struct {
+	file string // Config that references it
+	cert *x509.Certificate
+	err  error // Set when the certificate couldn't be loaded
+}
+
+// checkCerts loads every certificate the enabled configs reference, once
+// each. Certificates chosen at runtime through variables are skipped.
+func checkCerts(files []FileData) []certCheck {
+	var checks []certCheck
+	checked := map[string]bool{}
+	for _, file := range files {
+		if !file.enabled() {
+			continue
+		}
+		for _, path := range file.certs {
+			if checked[path] {
+				continue
+			}
+			checked[path] = true
+
+			cert, err := loadCert(path)
+			if errors.Is(err, errDynamicCert) {
+				continue
+			}
+			checks = append(checks, certCheck{file.Filename, cert, err})
+		}
+	}
+	return checks
+}
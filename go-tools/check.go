@@ -0,0 +1,96 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Nagios plugin exit codes
+const (
+	nagiosOK       = 0
+	nagiosWarning  = 1
+	nagiosCritical = 2
+)
+
+// 35. Check Functionality - One-line health summary for Nagios/Icinga
+func handleCheck(args []string) {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	warnDays := fs.Int("warn-days", 30, "WARNING when a certificate expires within this many days")
+	critDays := fs.Int("crit-days", 7, "CRITICAL when a certificate expires within this many days")
+	parseFlags(fs, args)
+
+	code := nagiosOK
+	var problems []string
+	raise := func(level int, problem string) {
+		if level > code {
+			code = level
+		}
+		problems = append(problems, problem)
+	}
+
+	if err := checkHealth("", cfg.Services, "", nil); err != nil {
+		raise(nagiosCritical, err.Error())
+	}
+	if _, err := testNginx(); err != nil {
+		raise(nagiosCritical, "nginx config test failed")
+	}
+
+	files, errs := scanConfigs()
+	if len(errs) > 0 {
+		raise(nagiosWarning, fmt.Sprintf("%d scan error(s)", len(errs)))
+	}
+	enabled := 0
+	for _, file := range files {
+		if file.enabled() {
+			enabled++
+		}
+	}
+
+	duplicates := duplicateServerNames(files)
+	if len(duplicates) > 0 {
+		var names []string
+		for name := range duplicates {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		raise(nagiosWarning, "duplicate server_name "+strings.Join(names, ", "))
+	}
+
+	// Perfdata reports the soonest expiry, even when it's fine
+	minDays, seenCert := 0, false
+	now := time.Now()
+	for _, check := range checkCerts(files) {
+		if check.err != nil {
+			raise(nagiosCritical, fmt.Sprintf("%s: unreadable certificate", check.file))
+			continue
+		}
+		days := int(check.cert.NotAfter.Sub(now).Hours() / 24)
+		if !seenCert || days < minDays {
+			minDays, seenCert = days, true
+		}
+		switch {
+		case days < *critDays:
+			raise(nagiosCritical, fmt.Sprintf("%s: certificate expires in %d days", check.file, days))
+		case days < *warnDays:
+			raise(nagiosWarning, fmt.Sprintf("%s: certificate expires in %d days", check.file, days))
+		}
+	}
+
+	status := [...]string{"OK", "WARNING", "CRITICAL"}[code]
+	message := fmt.Sprintf("%d enabled configs, nginx active, config valid", enabled)
+	if len(problems) > 0 {
+		message = strings.Join(problems, "; ")
+	}
+
+	perfdata := fmt.Sprintf("enabled=%d duplicate_names=%d", enabled, len(duplicates))
+	if seenCert {
+		// Thresholds are "N:", alerting when the value drops below N
+		perfdata += fmt.Sprintf(" cert_days=%d;%d:;%d:", minDays, *warnDays, *critDays)
+	}
+
+	fmt.Printf("%s - %s | %s\n", status, message, perfdata)
+	exit(code)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// directiveContexts lists, for directives that are commonly misplaced, the
+// blocks nginx accepts them in. The top level of a site config counts as
+// "http", since that's where conf.d is included. Directives not listed are
+// never flagged; add entries here to extend the check. stream {} has rules
+// of its own and isn't checked.
+var directiveContexts = map[string][]string{
+	"server":          {"http", "upstream"},
+	"upstream":        {"http"},
+	"location":        {"server", "location"},
+	"server_name":     {"server"},
+	"listen":          {"server"},
+	"proxy_pass":      {"location", "if", "limit_except"},
+	"fastcgi_pass":    {"location", "if"},
+	"uwsgi_pass":      {"location", "if"},
+	"alias":           {"location"},
+	"try_files":       {"server", "location"},
+	"limit_except":    {"location"},
+	"return":          {"server", "location", "if"},
+	"rewrite":         {"server", "location", "if"},
+	"ssl_certificate": {"http", "server"},
+}
+
+// dataBlocks hold lists of values rather than directives, so a word like
+// server_name inside them is just data
+var dataBlocks = []string{"map", "geo", "types", "split_clients", "charset_map"}
+
+// checkDirectiveContexts flags curated directives that appear in a block
+// nginx doesn't allow them in. A file without any server or upstream block
+// is taken to be a snippet included somewhere unknown, so only what's
+// nested inside its own blocks is checked.
+func checkDirectiveContexts(content []byte) []string {
+	directives := parseDirectives(string(content))
+
+	snippet := true
+	for _, d := range directives {
+		if len(d.context) == 0 && d.block && (d.name == "server" || d.name == "upstream") {
+			snippet = false
+		}
+	}
+
+	var problems []string
+	for _, d := range directives {
+		allowed, ok := directiveContexts[d.name]
+		if !ok || (snippet && len(d.context) == 0) || d.within("stream") {
+			continue
+		}
+		inData := false
+		for _, block := range dataBlocks {
+			inData = inData || d.within(block)
+		}
+		if inData {
+			continue
+		}
+
+		context := "http"
+		if len(d.context) > 0 {
+			context = d.context[len(d.context)-1]
+		}
+		if !contains(allowed, context) {
+			problems = append(problems, fmt.Sprintf("line %d: %s is not allowed in %s (only %s)",
+				d.line, d.name, context, strings.Join(allowed, ", ")))
+		}
+	}
+	return problems
+}
+
+// contains reports whether list holds s
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
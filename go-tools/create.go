@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+// templateData is what a site template is rendered with. Templates can use
+// either {{.ServerName}} or the {{SERVER_NAME}} shorthand.
+type templateData struct {
+	ServerName string
+}
+
+// renderTemplate renders the template file for the given server name
+func renderTemplate(path, serverName string) ([]byte, error) {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := template.New(filepath.Base(path)).
+		Option("missingkey=error").
+		Funcs(template.FuncMap{
+			"SERVER_NAME": func() string { return serverName },
+		}).
+		Parse(string(text))
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, templateData{ServerName: serverName}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// 7. Create Functionality - Stand up a new vhost from a template
+func handleCreate(args []string) {
+	fs := flag.NewFlagSet("create", flag.ExitOnError)
+	templateFile := fs.String("template", "", "template file to render")
+	serverName := fs.String("server-name", "", "value for the SERVER_NAME placeholder")
+	output := fs.String("output", "", "name of the config file to write")
+	disabled := fs.Bool("disabled", false, "write into the backup directory instead of enabling")
+	parseFlags(fs, args)
+
+	if *templateFile == "" || *serverName == "" || *output == "" {
+		fail(ErrUsage, "Usage: ./conf-mover create --template=file --server-name=example.com --output=example.conf [--disabled]")
+	}
+
+	rendered, err := renderTemplate(*templateFile, *serverName)
+	if err != nil {
+		fail(codeOf(err), "Error rendering template: %v", err)
+	}
+
+	installNewConf(*output, rendered, *disabled)
+}
+
+// installNewConf writes a brand new config into the nginx directory (or the
+// backup directory when disabled) and, for enabled configs, removes it again
+// if nginx rejects it. It exits on any failure.
+func installNewConf(name string, content []byte, disabled bool) {
+	dir := cfg.NginxDir
+	if disabled {
+		dir = cfg.BackupDir
+	}
+	if err := ensureDir(dir); err != nil {
+		fail(codeOf(err), "Error creating directory: %v", err)
+	}
+
+	// Never clobber an existing site
+	dst := filepath.Join(dir, confFilename(name))
+	if simulate {
+		if _, err := os.Lstat(dst); err == nil {
+			fail(ErrDstExists, "Error creating config: %s already exists", dst)
+		}
+		simulated("create %s (%d bytes)", dst, len(content))
+		if !disabled {
+			simulated("nginx -t")
+		}
+		fmt.Printf("Success: created %s\n", dst)
+		return
+	}
+
+	f, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		fail(codeOf(err), "Error creating config: %v", err)
+	}
+	_, err = f.Write(content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(dst)
+		fail(codeOf(err), "Error writing config: %v", err)
+	}
+
+	// Only enabled configs are loaded by nginx, so only they can be tested
+	if !disabled {
+		if output, err := testNginx(); err != nil {
+			os.Remove(dst)
+			fail(ErrNginxTest, "❌ Nginx config test failed, removed %s:\n%s", dst, output)
+		}
+		fmt.Println("✓ Nginx configuration test passed")
+	}
+
+	fmt.Printf("Success: created %s\n", dst)
+}
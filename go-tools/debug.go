@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// 17. Parse Functionality - Show how a single config is interpreted
+func handleParse(args []string) {
+	fs := flag.NewFlagSet("parse", flag.ExitOnError)
+	debug := fs.Bool("debug", false, "explain every server_name match and near-miss")
+	positional := parseFlags(fs, args)
+
+	if len(positional) != 1 {
+		fail(ErrUsage, "Usage: ./conf-mover parse [filename] [--debug]")
+	}
+
+	path, err := findConf(positional[0])
+	if err != nil {
+		fail(codeOf(err), "Error: %v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		fail(codeOf(err), "Error reading config: %v", err)
+	}
+
+	if *debug {
+		debugServerNames(string(content))
+	}
+
+	// Regexes are full of <, > and &, keep them readable
+	info := parseConf(string(content))
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	enc.Encode(struct {
+		ServerName  string       `json:"server_name"`
+		ServerNames []ServerName `json:"server_names"`
+	}{info.ServerName, info.ServerNames})
+}
+
+// debugServerNames prints each server_name directive the parser saw and
+// why it was used or ignored, then every other line mentioning server_name
+// along with the likely reason it didn't parse
+func debugServerNames(content string) {
+	lines := strings.Split(content, "\n")
+	explained := map[int]bool{}
+
+	for _, d := range parseDirectives(content) {
+		if d.name == "server_name" {
+			explained[d.line] = true
+			where := "top level"
+			if len(d.context) > 0 {
+				where = strings.Join(d.context, " > ")
+			}
+
+			note := ""
+			if d.unterminated {
+				note = " (missing ';', ended at the next directive)"
+			} else if d.split {
+				note = " (the directive before it is missing ';')"
+			}
+
+			switch {
+			case !d.in("server"):
+				fmt.Printf("line %d: ignored, server_name inside %s is not a server definition%s\n", d.line, where, note)
+			case len(d.args) == 0:
+				fmt.Printf("line %d: server_name with no names%s\n", d.line, note)
+			default:
+				fmt.Printf("line %d: matched [%s] in %s%s\n", d.line, strings.Join(d.args, " "), where, note)
+			}
+		}
+	}
+
+	for i, line := range lines {
+		if explained[i+1] || !strings.Contains(line, "server_name") {
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		if hash := strings.Index(trimmed, "#"); hash >= 0 && hash < strings.Index(trimmed, "server_name") {
+			fmt.Printf("line %d: commented out: %s\n", i+1, trimmed)
+		} else {
+			fmt.Printf("line %d: mentions server_name but isn't that directive: %s\n", i+1, trimmed)
+		}
+	}
+}
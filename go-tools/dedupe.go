@@ -0,0 +1,95 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// formatSize renders a byte count the way parseSize reads it
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1fG", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1fM", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1fK", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%dB", n)
+}
+
+// 39. Backup Dedupe Functionality - Drop versions identical to the one before
+func handleBackupDedupe(args []string) {
+	fs := flag.NewFlagSet("backup-dedupe", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "show which versions would be removed without removing them")
+	parseFlags(fs, args)
+
+	entries, err := os.ReadDir(cfg.VersionsDir)
+	if os.IsNotExist(err) {
+		fmt.Println("No versions saved yet")
+		return
+	}
+	if err != nil {
+		fail(codeOf(err), "Error reading versions directory: %v", err)
+	}
+
+	removed, failed := 0, 0
+	var reclaimed int64
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		filename := entry.Name()
+
+		paths, err := versionFiles(filename)
+		if err != nil {
+			fmt.Printf("❌ %s: %v\n", filename, err)
+			failed++
+			continue
+		}
+
+		// Compare each version with the one kept before it, so the
+		// earliest copy of a run of identical versions survives
+		previous := ""
+		for _, path := range paths {
+			hash, err := hashFile(path)
+			if err != nil {
+				fmt.Printf("❌ %s: %v\n", path, err)
+				failed++
+				previous = ""
+				continue
+			}
+			if hash != previous {
+				previous = hash
+				continue
+			}
+
+			info, err := os.Stat(path)
+			if err == nil && !*dryRun {
+				err = removeFile(path)
+			}
+			if err != nil {
+				fmt.Printf("❌ %s: %v\n", path, err)
+				failed++
+				continue
+			}
+			if *dryRun {
+				fmt.Printf("  would remove %s\n", path)
+			} else {
+				fmt.Printf("✓ removed %s\n", path)
+			}
+			removed++
+			reclaimed += info.Size()
+		}
+	}
+
+	if *dryRun {
+		fmt.Printf("Summary: %d duplicate version(s), %s would be reclaimed\n", removed, formatSize(reclaimed))
+	} else {
+		fmt.Printf("Summary: %d duplicate version(s) removed, %s reclaimed\n", removed, formatSize(reclaimed))
+	}
+	if failed > 0 {
+		exit(1)
+	}
+}
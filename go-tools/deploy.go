@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// 18. Deploy Functionality - Install a new config only if nginx accepts it
+func handleDeploy(args []string) {
+	if len(args) != 1 {
+		fail(ErrUsage, "Usage: ./conf-mover deploy [path/to/new.conf]")
+	}
+
+	newContent, err := os.ReadFile(args[0])
+	if err != nil {
+		fail(codeOf(err), "Error reading new config: %v", err)
+	}
+
+	filename := confFilename(args[0])
+	dst := filepath.Join(cfg.NginxDir, filename)
+
+	previous, err := os.ReadFile(dst)
+	hadPrevious := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		fail(codeOf(err), "Error reading current config: %v", err)
+	}
+
+	// Put things back the way they were if anything below fails
+	rollback := func() {
+		if hadPrevious {
+			err = writeFileAtomic(dst, previous)
+		} else {
+			err = removeFile(dst)
+		}
+		if err != nil {
+			fail(codeOf(err), "❌ Rollback failed, %s is in an unknown state: %v", dst, err)
+		}
+		fmt.Printf("↩ Restored previous state of %s\n", dst)
+	}
+
+	if err := ensureDir(cfg.NginxDir); err != nil {
+		fail(codeOf(err), "Error creating nginx directory: %v", err)
+	}
+	if err := writeFileAtomic(dst, newContent); err != nil {
+		fail(codeOf(err), "Error placing new config: %v", err)
+	}
+	fmt.Printf("✓ Placed %s\n", dst)
+
+	result := runReload()
+	if err := reportReload(result); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		rollback()
+		if !result.TestPassed {
+			fmt.Println("Nginx was not reloaded")
+		}
+		exit(1)
+	}
+
+	if hadPrevious {
+		path, err := saveVersion(filename, previous)
+		if err != nil {
+			fmt.Printf("Warning: could not archive previous version: %v\n", err)
+		} else {
+			fmt.Printf("✓ Previous version archived to %s\n", path)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import "fmt"
+
+// deprecation is a directive nginx has deprecated or dropped. When arg is
+// set only uses with that argument are flagged, e.g. "ssl on" but not
+// "ssl off".
+type deprecation struct {
+	directive string
+	arg       string
+	advice    string // What to use instead
+}
+
+// deprecations is the curated list checked by lint --deprecations. Add
+// entries here to extend the check.
+var deprecations = []deprecation{
+	{"ssl", "on", "use the ssl parameter of listen"},
+	{"listen", "http2", `use "http2 on;" in the server block (nginx 1.25.1+)`},
+	{"listen", "spdy", `use "http2 on;", SPDY was removed in nginx 1.9.5`},
+	{"listen", "default", "use the default_server parameter"},
+	{"limit_zone", "", "use limit_conn_zone"},
+	{"satisfy_any", "", `use "satisfy any;"`},
+	{"optimize_server_names", "", "use server_name_in_redirect"},
+	{"open_file_cache_retest", "", "use open_file_cache_valid"},
+	{"http2_push", "", "server push was removed in nginx 1.25.1"},
+	{"http2_push_preload", "", "server push was removed in nginx 1.25.1"},
+	{"http2_idle_timeout", "", "use keepalive_timeout"},
+	{"http2_recv_timeout", "", "use client_header_timeout"},
+	{"http2_max_requests", "", "use keepalive_requests"},
+	{"http2_max_field_size", "", "use large_client_header_buffers"},
+	{"http2_max_header_size", "", "use large_client_header_buffers"},
+	{"spdy_headers_comp", "", "SPDY was removed in nginx 1.9.5"},
+}
+
+// checkDeprecations flags every use of a directive in deprecations
+func checkDeprecations(content []byte) []string {
+	var problems []string
+	for _, d := range parseDirectives(string(content)) {
+		for _, dep := range deprecations {
+			if d.name != dep.directive || (dep.arg != "" && !contains(d.args, dep.arg)) {
+				continue
+			}
+			use := d.name
+			if dep.arg != "" {
+				use += " " + dep.arg
+			}
+			problems = append(problems, fmt.Sprintf("line %d: %s is deprecated, %s", d.line, use, dep.advice))
+		}
+	}
+	return problems
+}
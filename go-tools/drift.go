@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+// 26. Drift Functionality - Live configs that have moved on from their backup
+func handleDrift(args []string) {
+	fs := flag.NewFlagSet("drift", flag.ExitOnError)
+	staleDays := fs.Int("stale-days", 30, "flag backups older than this when the live file has changed since")
+	parseFlags(fs, args)
+
+	files, errs := scanConfigs()
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	backups := map[string]FileData{}
+	for _, file := range files {
+		if !file.enabled() {
+			backups[file.Filename] = file
+		}
+	}
+
+	now := time.Now()
+	stale := time.Duration(*staleDays) * 24 * time.Hour
+	pairs, flagged := 0, 0
+	for _, live := range files {
+		backup, ok := backups[live.Filename]
+		if !live.enabled() || !ok {
+			continue
+		}
+		pairs++
+
+		liveHash, err := hashFile(live.Path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			continue
+		}
+		backupHash, err := hashFile(backup.Path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			continue
+		}
+
+		backupAge := relativeTime(backup.ModTime, now)
+		if liveHash == backupHash {
+			fmt.Printf("✓ %s: matches backup (backed up %s)\n", live.Filename, backupAge)
+			continue
+		}
+
+		changed := relativeTime(live.ModTime, now)
+		if now.Sub(backup.ModTime) > stale && live.ModTime.After(backup.ModTime) {
+			fmt.Printf("❌ %s: live changed %s but backup is from %s, re-back it up\n", live.Filename, changed, backupAge)
+			flagged++
+		} else {
+			fmt.Printf("⚠ %s: differs from backup (backed up %s, live changed %s)\n", live.Filename, backupAge, changed)
+		}
+	}
+
+	if pairs == 0 {
+		fmt.Printf("No config exists in both %s and %s\n", cfg.NginxDir, cfg.BackupDir)
+		return
+	}
+	if flagged > 0 {
+		exit(1)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// DiskUsage is the size of one of the places conf-mover keeps files
+type DiskUsage struct {
+	Name  string `json:"name"`
+	Path  string `json:"path"`
+	Files int    `json:"files"`
+	Bytes int64  `json:"bytes"`
+}
+
+// fileUsage totals the sizes of paths
+func fileUsage(name, path string, paths []string) DiskUsage {
+	usage := DiskUsage{Name: name, Path: path}
+	for _, file := range paths {
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			usage.Files++
+			usage.Bytes += info.Size()
+		}
+	}
+	return usage
+}
+
+// treeUsage totals every regular file below root; a missing root is empty
+func treeUsage(name, root string) (DiskUsage, error) {
+	usage := DiskUsage{Name: name, Path: root}
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if os.IsNotExist(err) && path == root {
+			return filepath.SkipDir
+		}
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		usage.Files++
+		usage.Bytes += info.Size()
+		return nil
+	})
+	return usage, err
+}
+
+// 42. Disk Usage Functionality - How much space configs and backups take
+func handleDU(args []string) {
+	fs := flag.NewFlagSet("du", flag.ExitOnError)
+	output := fs.String("output", "text", "output format: text or json")
+	parseFlags(fs, args)
+
+	if *output != "text" && *output != "json" {
+		fail(ErrInvalid, "Error: unknown output format %q, use text or json", *output)
+	}
+
+	enabled, err := confPaths(cfg.NginxDir)
+	if err != nil {
+		fail(codeOf(err), "Error reading nginx directory: %v", err)
+	}
+	disabled, err := confPaths(cfg.BackupDir)
+	if err != nil {
+		fail(codeOf(err), "Error reading backup directory: %v", err)
+	}
+
+	usages := []DiskUsage{
+		fileUsage("enabled", cfg.NginxDir, enabled),
+		fileUsage("disabled", cfg.BackupDir, disabled),
+	}
+	for _, dir := range []struct{ name, path string }{
+		{"versions", cfg.VersionsDir},
+		{"snapshots", cfg.SnapshotDir},
+	} {
+		usage, err := treeUsage(dir.name, dir.path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		usages = append(usages, usage)
+	}
+	usages = append(usages,
+		fileUsage("state", cfg.StateFile, []string{cfg.StateFile}),
+		fileUsage("audit log", cfg.AuditLog, []string{cfg.AuditLog}),
+	)
+
+	if *output == "json" {
+		jsonOutput, _ := json.MarshalIndent(usages, "", "  ")
+		fmt.Println(string(jsonOutput))
+		return
+	}
+
+	var totalFiles int
+	var totalBytes int64
+	for _, usage := range usages {
+		fmt.Printf("  %-10s %8s %6d file(s)  %s\n", usage.Name, formatSize(usage.Bytes), usage.Files, usage.Path)
+		totalFiles += usage.Files
+		totalBytes += usage.Bytes
+	}
+	fmt.Printf("  %-10s %8s %6d file(s)\n", "total", formatSize(totalBytes), totalFiles)
+}
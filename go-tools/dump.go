@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// dumpSections splits nginx -T output into the files it concatenates
+func dumpSections(dump string) ([]string, map[string]string) {
+	const marker = "# configuration file "
+	var order []string
+	sections := map[string]string{}
+
+	current := ""
+	var body strings.Builder
+	flush := func() {
+		if current != "" {
+			sections[current] = body.String()
+		}
+		body.Reset()
+	}
+	for _, line := range strings.SplitAfter(dump, "\n") {
+		if strings.HasPrefix(line, marker) && strings.HasSuffix(strings.TrimSpace(line), ":") {
+			flush()
+			current = strings.TrimSuffix(strings.TrimSpace(strings.TrimPrefix(line, marker)), ":")
+			order = append(order, current)
+			continue
+		}
+		body.WriteString(line)
+	}
+	flush()
+
+	return order, sections
+}
+
+// printExpanded prints content with the files its include directives pull
+// in, as nginx saw them, indented below each include
+func printExpanded(content string, sections map[string]string, indent string, depth int) {
+	fmt.Println(indentLines(strings.TrimRight(content, "\n"), indent))
+	if depth > 8 {
+		return
+	}
+	for _, d := range parseDirectives(content) {
+		if d.name != "include" || len(d.args) == 0 {
+			continue
+		}
+		for _, file := range resolveInclude(d.args[0]).Files {
+			if included, ok := sections[file]; ok {
+				fmt.Printf("%s# --- included from line %d: %s ---\n", indent+"    ", d.line, file)
+				printExpanded(included, sections, indent+"    ", depth+1)
+			}
+		}
+	}
+}
+
+// indentLines prefixes every line of text with indent
+func indentLines(text, indent string) string {
+	if indent == "" {
+		return text
+	}
+	return indent + strings.ReplaceAll(text, "\n", "\n"+indent)
+}
+
+// 36. Dump Functionality - nginx's own view of the effective configuration
+func handleDump(args []string) {
+	fs := flag.NewFlagSet("dump", flag.ExitOnError)
+	serverName := fs.String("server-name", "", "only show the server blocks answering to this name, with their includes")
+	parseFlags(fs, args)
+
+	var stdout, stderr bytes.Buffer
+	cmd := nginxCommand("", "nginx", "-T")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		fmt.Print(errorLine(ErrNginxTest, fmt.Sprintf("❌ nginx -T failed:\n%s%s", stderr.String(), stdout.String())))
+		exit(1)
+	}
+
+	if *serverName == "" {
+		fmt.Print(stdout.String())
+		return
+	}
+
+	order, sections := dumpSections(stdout.String())
+	found := 0
+	for _, file := range order {
+		content := sections[file]
+		directives := parseDirectives(content)
+		for _, d := range directives {
+			// stream {} servers have no server_name to match
+			if d.name != "server" || !d.block || d.within("stream") {
+				continue
+			}
+			if !serverBlockNamed(d, directives, *serverName) {
+				continue
+			}
+			fmt.Printf("# %s:%d\n", file, d.line)
+			printExpanded(content[d.start:d.end], sections, "", 0)
+			fmt.Println()
+			found++
+		}
+	}
+
+	if found == 0 {
+		fail(ErrNotFound, "No server block in the effective configuration answers to %s", *serverName)
+	}
+}
+
+// serverBlockNamed reports whether the server block d lists name in one of
+// its own server_name directives
+func serverBlockNamed(d directive, directives []directive, name string) bool {
+	for _, other := range directives {
+		if other.name != "server_name" || other.start < d.start || other.end > d.end || !other.in("server") {
+			continue
+		}
+		for _, arg := range other.args {
+			if strings.EqualFold(arg, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
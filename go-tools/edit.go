@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// runEditor opens path in $EDITOR (vi if unset) on the current terminal
+func runEditor(path string) error {
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+
+	// $EDITOR may carry its own arguments, e.g. "code --wait"
+	fields := strings.Fields(editor)
+	cmd := exec.Command(fields[0], append(fields[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// confirm asks a yes/no question, defaulting to yes
+func confirm(question string) bool {
+	fmt.Printf("%s [Y/n] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "" || answer == "y" || answer == "yes"
+}
+
+// 23. Edit Functionality - Edit a config and only save it if nginx accepts it
+func handleEdit(args []string) {
+	if len(args) != 1 {
+		fail(ErrUsage, "Usage: ./conf-mover edit [filename]")
+	}
+
+	path, err := findConf(args[0])
+	if err != nil {
+		fail(codeOf(err), "Error: %v", err)
+	}
+	original, err := os.ReadFile(path)
+	if err != nil {
+		fail(codeOf(err), "Error reading config: %v", err)
+	}
+
+	// Edit a copy with the same name so the editor picks the right syntax
+	dir, err := os.MkdirTemp("", "conf-mover-edit-")
+	if err != nil {
+		fail(codeOf(err), "Error creating temp directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	draft := filepath.Join(dir, filepath.Base(path))
+	if err := os.WriteFile(draft, original, 0644); err != nil {
+		fail(codeOf(err), "Error creating draft: %v", err)
+	}
+
+	for {
+		if err := runEditor(draft); err != nil {
+			fail(codeOf(err), "Error running editor: %v", err)
+		}
+
+		edited, err := os.ReadFile(draft)
+		if err != nil {
+			fail(codeOf(err), "Error reading draft: %v", err)
+		}
+		if bytes.Equal(edited, original) {
+			fmt.Println("No changes made")
+			return
+		}
+
+		output, err := testConfFile(draft)
+		if err == nil {
+			if _, err := saveVersion(filepath.Base(path), original); err != nil {
+				fmt.Printf("Warning: could not archive previous version: %v\n", err)
+			}
+			if err := writeFileAtomic(path, edited); err != nil {
+				fail(codeOf(err), "Error saving %s: %v", path, err)
+			}
+			fmt.Printf("✓ Saved %s\n", path)
+			return
+		}
+
+		fmt.Printf("❌ Edited config failed validation:\n%s", output)
+		if !confirm("Re-edit?") {
+			fmt.Printf("↩ Kept the previous version of %s\n", path)
+			exit(1)
+		}
+	}
+}
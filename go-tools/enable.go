@@ -0,0 +1,256 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// setReenable records (or, with a zero time, clears) when resume should
+// enable filename again
+func setReenable(filename string, at time.Time) error {
+	state, err := loadState()
+	if err != nil {
+		return err
+	}
+
+	if at.IsZero() {
+		if _, ok := state.ReenableAt[filename]; !ok {
+			return nil
+		}
+		delete(state.ReenableAt, filename)
+	} else {
+		if state.ReenableAt == nil {
+			state.ReenableAt = map[string]time.Time{}
+		}
+		state.ReenableAt[filename] = at
+	}
+	return saveState(state)
+}
+
+// 13. Enable Functionality - Shorthand for move restore
+func handleEnable(args []string) {
+	fs := flag.NewFlagSet("enable", flag.ExitOnError)
+	reload := fs.Bool("reload", false, "test and reload nginx after enabling")
+	revert := fs.Bool("revert-on-failure", false, "disable again if the config test fails (implies --reload)")
+	mode := fs.String("mode", cfg.EnableMode, "how to place the config: move, copy or symlink (default ENABLE_MODE or move)")
+	copyFile := fs.Bool("copy", false, "copy instead of moving, keeping the original in the backup directory (same as --mode=copy)")
+	positional := parseFlags(fs, args)
+
+	if len(positional) != 1 {
+		fail(ErrUsage, "Usage: ./conf-mover enable [filename] [--copy | --mode=move|copy|symlink] [--reload] [--revert-on-failure]")
+	}
+	if *copyFile {
+		*mode = "copy"
+	}
+	if !enableModes[*mode] {
+		fail(ErrInvalid, "Error: unknown mode %q, use move, copy or symlink", *mode)
+	}
+	cfg.EnableMode = *mode
+
+	filename := confFilename(positional[0])
+	src, dst, err := moveConf("restore", filename)
+	if err != nil {
+		fail(codeOf(err), "Error: %v", err)
+	}
+	verb := map[string]string{"move": "moved", "copy": "copied", "symlink": "linked"}[cfg.EnableMode]
+	fmt.Printf("Success: %s %s %s -> %s\n", filename, verb, src, dst)
+
+	// Enabling by hand supersedes any scheduled re-enable
+	if err := setReenable(filename, time.Time{}); err != nil {
+		fmt.Printf("Warning: could not update state file: %v\n", err)
+	}
+
+	if *reload || *revert {
+		if err := reloadAfterMove("restore", []string{filename}, *revert); err != nil {
+			exit(1)
+		}
+	}
+}
+
+// 14. Disable Functionality - Shorthand for move backup, optionally timed
+func handleDisable(args []string) {
+	fs := flag.NewFlagSet("disable", flag.ExitOnError)
+	duration := fs.Duration("for", 0, "re-enable automatically after this long (e.g. 2h), via resume")
+	reload := fs.Bool("reload", false, "test and reload nginx after disabling")
+	revert := fs.Bool("revert-on-failure", false, "enable again if the config test fails (implies --reload)")
+	match := fs.String("match", "", "disable every enabled config with a server_name matching this glob")
+	dryRun := fs.Bool("dry-run", false, "with --match, only list what would be disabled")
+	output := fs.String("output", "text", "output format: text or json (per-file results)")
+	force := fs.Bool("force", false, "disable pinned configs too")
+	positional := parseFlags(fs, args)
+
+	if (*match == "") == (len(positional) == 0) || len(positional) > 1 {
+		fail(ErrUsage, "Usage: ./conf-mover disable [filename | --match=*.example.com [--dry-run]] [--for=2h] [--reload] [--revert-on-failure] [--force]")
+	}
+	if *duration < 0 {
+		fail(ErrInvalid, "Error: --for must be positive")
+	}
+
+	results := newResultCollector(*output)
+
+	var filenames []string
+	if *match != "" {
+		var err error
+		if filenames, err = matchingConfigs(*match); err != nil {
+			fail(codeOf(err), "Error: %v", err)
+		}
+		if len(filenames) == 0 {
+			fmt.Printf("No enabled config has a server_name matching %s\n", *match)
+			results.flush()
+			return
+		}
+	} else {
+		filenames = []string{confFilename(positional[0])}
+	}
+
+	// Pinned configs are skipped, or refused outright when named directly
+	var unpinned []string
+	for _, filename := range filenames {
+		ok, err := mayDisable(filename, *force, *match != "")
+		if err != nil {
+			fail(codeOf(err), "Error: %v", err)
+		}
+		if !ok {
+			results.addStatus(filename, "disable", "skipped")
+			continue
+		}
+		unpinned = append(unpinned, filename)
+	}
+	filenames = unpinned
+
+	if *match != "" && *dryRun {
+		for _, filename := range filenames {
+			fmt.Printf("Would disable: %s\n", filename)
+			results.addStatus(filename, "disable", "planned")
+		}
+		fmt.Printf("%d config(s) would be disabled\n", len(filenames))
+		results.flush()
+		return
+	}
+
+	var at time.Time
+	if *duration > 0 {
+		at = time.Now().Add(*duration)
+	}
+
+	var disabled []string
+	for _, filename := range filenames {
+		// A copied or linked config is disabled by removing it
+		_, err := os.Stat(filepath.Join(cfg.BackupDir, filename))
+		removing := cfg.EnableMode != "move" && err == nil
+
+		src, dst, err := moveConf("backup", filename)
+		results.add(filename, "disable", err)
+		if err != nil {
+			fmt.Printf("❌ %s: %v\n", filename, err)
+			continue
+		}
+		if removing {
+			fmt.Printf("Success: %s removed %s (kept %s)\n", filename, src, dst)
+		} else {
+			fmt.Printf("Success: %s moved %s -> %s\n", filename, src, dst)
+		}
+		disabled = append(disabled, filename)
+
+		if err := setReenable(filename, at); err != nil {
+			fmt.Printf("Warning: could not update state file: %v\n", err)
+		} else if !at.IsZero() {
+			fmt.Printf("Scheduled: %s re-enables at %s (run resume to apply)\n", filename, at.Format(time.RFC3339))
+		}
+	}
+
+	if *match != "" {
+		fmt.Printf("%d of %d matching config(s) disabled\n", len(disabled), len(filenames))
+	}
+
+	if len(disabled) > 0 && (*reload || *revert) {
+		results.add("", "reload", reloadAfterMove("backup", disabled, *revert))
+	}
+
+	results.flush()
+	if results.failed() > 0 {
+		exit(1)
+	}
+}
+
+// matchingConfigs returns the enabled configs with at least one
+// server_name matching the glob pattern
+func matchingConfigs(pattern string) ([]string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %v", pattern, err)
+	}
+
+	files, errs := scanConfigs()
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	var filenames []string
+	for _, file := range files {
+		if !file.enabled() {
+			continue
+		}
+		for _, name := range file.ServerNames {
+			if matched, _ := path.Match(pattern, name.Name); matched {
+				filenames = append(filenames, file.Filename)
+				break
+			}
+		}
+	}
+	return filenames, nil
+}
+
+// 15. Resume Functionality - Re-enable configs whose disable window is over.
+// Meant to be run periodically, e.g. from cron.
+func handleResume() {
+	state, err := loadState()
+	if err != nil {
+		fail(codeOf(err), "Error reading state file: %v", err)
+	}
+
+	var names []string
+	for name := range state.ReenableAt {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	now := time.Now()
+	restored, failed := 0, 0
+	for _, name := range names {
+		at := state.ReenableAt[name]
+		if at.After(now) {
+			fmt.Printf("Waiting: %s re-enables at %s\n", name, at.Format(time.RFC3339))
+			continue
+		}
+
+		src, dst, err := moveConf("restore", name)
+		if err != nil {
+			fmt.Printf("❌ %s: %v\n", name, err)
+			failed++
+			continue
+		}
+		fmt.Printf("Success: %s moved %s -> %s\n", name, src, dst)
+		delete(state.ReenableAt, name)
+		restored++
+	}
+
+	if err := saveState(state); err != nil {
+		fmt.Printf("Warning: could not update state file: %v\n", err)
+	}
+
+	if restored > 0 {
+		if err := reloadNginx(); err != nil {
+			fail(codeOf(err), "❌ %v", err)
+		}
+	} else if failed == 0 {
+		fmt.Println("✓ Nothing due")
+	}
+	if failed > 0 {
+		exit(1)
+	}
+}
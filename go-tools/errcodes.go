@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// errorCode is a stable identifier for a kind of failure, so scripts can
+// branch on what went wrong without parsing the message
+type errorCode string
+
+const (
+	ErrUsage      errorCode = "ERR_USAGE"       // Wrong arguments
+	ErrInvalid    errorCode = "ERR_INVALID"     // An argument or flag value is unacceptable
+	ErrSrcMissing errorCode = "ERR_SRC_MISSING" // The file to move or edit isn't there
+	ErrDstExists  errorCode = "ERR_DST_EXISTS"  // The target would be overwritten
+	ErrNotFound   errorCode = "ERR_NOT_FOUND"   // Some other file or directory is missing
+	ErrPermission errorCode = "ERR_PERMISSION"  // The filesystem refused access
+	ErrPinned     errorCode = "ERR_PINNED"      // The config is pinned
+	ErrNginxTest  errorCode = "ERR_NGINX_TEST"  // nginx -t rejected the configuration
+	ErrReload     errorCode = "ERR_RELOAD"      // A service failed to reload
+	ErrHealth     errorCode = "ERR_HEALTH"      // nginx didn't come back healthy
+	ErrIO         errorCode = "ERR_IO"          // Any other failure
+)
+
+// codedError attaches an errorCode to an error
+type codedError struct {
+	Code errorCode
+	Err  error
+}
+
+func (e *codedError) Error() string { return e.Err.Error() }
+func (e *codedError) Unwrap() error { return e.Err }
+
+// withCode returns err tagged with code
+func withCode(code errorCode, err error) error {
+	return &codedError{code, err}
+}
+
+// codeOf returns the code err carries, or the best guess from the
+// filesystem error underneath it
+func codeOf(err error) errorCode {
+	var coded *codedError
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &coded):
+		return coded.Code
+	case errors.Is(err, fs.ErrPermission):
+		return ErrPermission
+	case errors.Is(err, fs.ErrNotExist):
+		return ErrNotFound
+	case errors.Is(err, fs.ErrExist):
+		return ErrDstExists
+	}
+	return ErrIO
+}
+
+// parseableErrors is set by the global --parseable flag
+var parseableErrors bool
+
+// errorLine formats a failure message, prefixed with its code when
+// --parseable is set, e.g. "ERR_SRC_MISSING: Error: source file ..."
+func errorLine(code errorCode, message string) string {
+	if parseableErrors {
+		return fmt.Sprintf("%s: %s", code, message)
+	}
+	return message
+}
+
+// fail prints a failure message and exits
+func fail(code errorCode, format string, args ...interface{}) {
+	fmt.Println(errorLine(code, fmt.Sprintf(format, args...)))
+	exit(1)
+}
+
+// atExit holds what has to run before the process ends, e.g. writing
+// profiles
+var atExit []func()
+
+// runAtExit runs the atExit hooks
+func runAtExit() {
+	for _, hook := range atExit {
+		hook()
+	}
+}
+
+// exitStatus is what the process is exiting with, for the atExit hooks
+var exitStatus int
+
+// exit is os.Exit after the atExit hooks. Commands exit through it so
+// failures still e.g. write their profiles.
+func exit(code int) {
+	exitStatus = code
+	runAtExit()
+	os.Exit(code)
+}
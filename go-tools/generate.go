@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// siteSpec describes a simple server block. It can come from flags or from
+// a JSON or flat YAML file using the same keys.
+type siteSpec struct {
+	ServerNames []string `json:"server_names"`
+	Listens     []string `json:"listens"`
+	ProxyPass   string   `json:"proxy_pass"`
+	Root        string   `json:"root"`
+	SSLCert     string   `json:"ssl_cert"`
+	SSLKey      string   `json:"ssl_key"`
+}
+
+// loadSiteSpec reads a spec file. YAML is the same flat "key: value" subset
+// the config loader accepts, with lists written comma-separated.
+func loadSiteSpec(path string) (siteSpec, error) {
+	var spec siteSpec
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return spec, err
+	}
+
+	if filepath.Ext(path) == ".json" {
+		err = json.Unmarshal(data, &spec)
+		return spec, err
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || line == "---" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return spec, fmt.Errorf("%s: expected \"key: value\", got %q", path, line)
+		}
+		value = yamlValue(strings.TrimSpace(value))
+
+		var list stringList
+		list.Set(value)
+		switch strings.TrimSpace(key) {
+		case "server_names", "server_name":
+			spec.ServerNames = list
+		case "listens", "listen":
+			spec.Listens = list
+		case "proxy_pass":
+			spec.ProxyPass = value
+		case "root":
+			spec.Root = value
+		case "ssl_cert":
+			spec.SSLCert = value
+		case "ssl_key":
+			spec.SSLKey = value
+		default:
+			return spec, fmt.Errorf("%s: unknown key %q", path, key)
+		}
+	}
+	return spec, nil
+}
+
+// validate checks the spec is complete enough to render
+func (spec siteSpec) validate() error {
+	if len(spec.ServerNames) == 0 {
+		return fmt.Errorf("at least one server name is required")
+	}
+	if (spec.ProxyPass == "") == (spec.Root == "") {
+		return fmt.Errorf("exactly one of proxy_pass or root is required")
+	}
+	if (spec.SSLCert == "") != (spec.SSLKey == "") {
+		return fmt.Errorf("ssl_cert and ssl_key must be given together")
+	}
+	for _, listen := range spec.Listens {
+		if strings.TrimSpace(listen) == "" {
+			return fmt.Errorf("listen addresses can't be empty")
+		}
+	}
+	return nil
+}
+
+// render assembles the server block. Without explicit listens it listens
+// on 443 with a certificate and on 80 without; port 443 listens get the
+// ssl flag whenever a certificate is set.
+func (spec siteSpec) render() []byte {
+	listens := spec.Listens
+	if len(listens) == 0 {
+		listens = []string{"80"}
+		if spec.SSLCert != "" {
+			listens = []string{"443"}
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("server {\n")
+	for _, listen := range listens {
+		if spec.SSLCert != "" && strings.HasSuffix(normalizeListenAddress(strings.Fields(listen)[0]), ":443") && !strings.Contains(listen, "ssl") {
+			listen += " ssl"
+		}
+		fmt.Fprintf(&b, "    listen %s;\n", listen)
+	}
+	fmt.Fprintf(&b, "    server_name %s;\n", strings.Join(spec.ServerNames, " "))
+
+	if spec.SSLCert != "" {
+		fmt.Fprintf(&b, "\n    ssl_certificate %s;\n", spec.SSLCert)
+		fmt.Fprintf(&b, "    ssl_certificate_key %s;\n", spec.SSLKey)
+	}
+
+	if spec.Root != "" {
+		fmt.Fprintf(&b, "\n    root %s;\n", spec.Root)
+		b.WriteString("    index index.html;\n")
+	}
+
+	b.WriteString("\n    location / {\n")
+	if spec.ProxyPass != "" {
+		fmt.Fprintf(&b, "        proxy_pass %s;\n", spec.ProxyPass)
+		b.WriteString("        proxy_set_header Host $host;\n")
+		b.WriteString("        proxy_set_header X-Real-IP $remote_addr;\n")
+		b.WriteString("        proxy_set_header X-Forwarded-For $proxy_add_x_forwarded_for;\n")
+		b.WriteString("        proxy_set_header X-Forwarded-Proto $scheme;\n")
+	} else {
+		b.WriteString("        try_files $uri $uri/ =404;\n")
+	}
+	b.WriteString("    }\n}\n")
+
+	return []byte(b.String())
+}
+
+// 27. Generate Functionality - Build a vhost from a declarative description
+func handleGenerate(args []string) {
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	input := fs.String("input", "", "JSON or YAML file describing the site")
+	var serverNames, listens stringList
+	fs.Var(&serverNames, "server-name", "name the site answers to (repeatable)")
+	fs.Var(&listens, "listen", "listen address or port (repeatable, default 80, or 443 with a certificate)")
+	proxyPass := fs.String("proxy-pass", "", "upstream URL to proxy to")
+	root := fs.String("root", "", "directory to serve static files from")
+	sslCert := fs.String("ssl-cert", "", "certificate file")
+	sslKey := fs.String("ssl-key", "", "certificate key file")
+	output := fs.String("output", "", "name of the config file to write")
+	disabled := fs.Bool("disabled", false, "write into the backup directory instead of enabling")
+	dryRun := fs.Bool("dry-run", false, "print the config instead of writing it")
+	parseFlags(fs, args)
+
+	var spec siteSpec
+	if *input != "" {
+		var err error
+		if spec, err = loadSiteSpec(*input); err != nil {
+			fail(codeOf(err), "Error reading site description: %v", err)
+		}
+	}
+
+	// Flags override the input file
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "server-name":
+			spec.ServerNames = serverNames
+		case "listen":
+			spec.Listens = listens
+		case "proxy-pass":
+			spec.ProxyPass = *proxyPass
+		case "root":
+			spec.Root = *root
+		case "ssl-cert":
+			spec.SSLCert = *sslCert
+		case "ssl-key":
+			spec.SSLKey = *sslKey
+		}
+	})
+
+	if *output == "" && !*dryRun {
+		fail(ErrUsage, "Usage: ./conf-mover generate [--input=site.json] --server-name=example.com [--listen=443] (--proxy-pass=URL|--root=DIR) [--ssl-cert=file --ssl-key=file] --output=example.conf [--disabled] [--dry-run]")
+	}
+	if err := spec.validate(); err != nil {
+		fail(codeOf(err), "Error: %v", err)
+	}
+
+	rendered := spec.render()
+	if *dryRun {
+		fmt.Print(string(rendered))
+		return
+	}
+	installNewConf(*output, rendered, *disabled)
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// git runs a git command inside dir and returns its combined output
+func git(dir string, args ...string) ([]byte, error) {
+	return exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+}
+
+// requireGitRepo returns a readable error unless dir is inside a work tree
+func requireGitRepo(dir string) error {
+	if _, err := exec.LookPath("git"); err != nil {
+		return fmt.Errorf("git is not installed")
+	}
+	if output, err := git(dir, "rev-parse", "--is-inside-work-tree"); err != nil || strings.TrimSpace(string(output)) != "true" {
+		return fmt.Errorf("%s is not tracked by git", dir)
+	}
+	return nil
+}
+
+// 20. Git Diff Functionality - Uncommitted changes to one config
+func handleGitDiff(args []string) {
+	if len(args) != 1 {
+		fail(ErrUsage, "Usage: ./conf-mover git-diff [filename]")
+	}
+
+	path, err := findConf(args[0])
+	if err != nil {
+		fail(codeOf(err), "Error: %v", err)
+	}
+
+	dir := filepath.Dir(path)
+	if err := requireGitRepo(dir); err != nil {
+		fail(codeOf(err), "Error: %v", err)
+	}
+
+	output, err := git(dir, "diff", "--", filepath.Base(path))
+	if err != nil {
+		fail(ErrIO, "Error running git diff:\n%s", output)
+	}
+	if len(output) == 0 {
+		fmt.Printf("✓ %s has no uncommitted changes\n", filepath.Base(path))
+		return
+	}
+	fmt.Print(string(output))
+}
+
+// 21. Git Status Functionality - Which configs have local modifications
+func handleGitStatus() {
+	if isGlobPattern(cfg.NginxDir) {
+		fail(ErrInvalid, "Error: NGINX_DIR is a glob pattern (%s); git-status needs a directory", cfg.NginxDir)
+	}
+	if err := requireGitRepo(cfg.NginxDir); err != nil {
+		fail(codeOf(err), "Error: %v", err)
+	}
+
+	// Porcelain paths are relative to the repository root; strip NginxDir's
+	// place in it so they read like filenames
+	prefix, err := git(cfg.NginxDir, "rev-parse", "--show-prefix")
+	if err != nil {
+		fail(ErrIO, "Error running git rev-parse:\n%s", prefix)
+	}
+	relative := func(path string) string {
+		return strings.TrimPrefix(path, strings.TrimSpace(string(prefix)))
+	}
+
+	output, err := git(cfg.NginxDir, "status", "--porcelain", "--untracked-files=all", "--", ".")
+	if err != nil {
+		fail(ErrIO, "Error running git status:\n%s", output)
+	}
+
+	labels := map[byte]string{'M': "modified", 'A': "added", 'D': "deleted", 'R': "renamed", '?': "untracked"}
+	count := 0
+	for _, line := range strings.Split(string(output), "\n") {
+		if len(line) < 4 || !strings.HasSuffix(line, ".conf") {
+			continue
+		}
+
+		// Porcelain format: two status letters (staged, unstaged), then the path
+		code := line[1]
+		if code == ' ' {
+			code = line[0]
+		}
+		label, ok := labels[code]
+		if !ok {
+			label = strings.TrimSpace(line[:2])
+		}
+
+		// Renames are "old -> new"
+		paths := strings.Split(line[3:], " -> ")
+		for i := range paths {
+			paths[i] = relative(paths[i])
+		}
+		fmt.Printf("  %-10s %s\n", label, strings.Join(paths, " -> "))
+		count++
+	}
+
+	if count == 0 {
+		fmt.Println("✓ No local modifications to tracked configs")
+	}
+}
+
+// 28. Changed Since Functionality - Configs touched since a git ref
+func handleChangedSince(args []string) {
+	if len(args) != 1 {
+		fail(ErrUsage, "Usage: ./conf-mover changed-since [git-ref]")
+	}
+	ref := args[0]
+
+	if isGlobPattern(cfg.NginxDir) {
+		fail(ErrInvalid, "Error: NGINX_DIR is a glob pattern (%s); changed-since needs a directory", cfg.NginxDir)
+	}
+	if err := requireGitRepo(cfg.NginxDir); err != nil {
+		fail(codeOf(err), "Error: %v", err)
+	}
+	if output, err := git(cfg.NginxDir, "rev-parse", "--verify", "--quiet", ref+"^{commit}"); err != nil {
+		fmt.Print(errorLine(ErrInvalid, fmt.Sprintf("Error: %q is not a commit in this repository\n%s", ref, output)))
+		exit(1)
+	}
+
+	// Paths relative to NginxDir so they read like filenames
+	output, err := git(cfg.NginxDir, "diff", "--name-status", "--relative", ref, "HEAD", "--", ".")
+	if err != nil {
+		fail(ErrIO, "Error running git diff:\n%s", output)
+	}
+
+	labels := map[byte]string{'M': "modified", 'A': "added", 'D': "deleted", 'R': "renamed", 'C': "copied", 'T': "retyped"}
+	count := 0
+	for _, line := range strings.Split(string(output), "\n") {
+		// Status letter(s), a tab, then the path (old and new path for renames)
+		fields := strings.Split(line, "\t")
+		if len(fields) < 2 {
+			continue
+		}
+		name := fields[len(fields)-1]
+		if !strings.HasSuffix(name, ".conf") {
+			continue
+		}
+		label, ok := labels[fields[0][0]]
+		if !ok {
+			label = fields[0]
+		}
+
+		// Names as of HEAD, or the names a deleted file used to serve
+		rev := "HEAD"
+		if label == "deleted" {
+			rev = ref
+		}
+		content, err := git(cfg.NginxDir, "show", rev+":./"+name)
+
+		names := "(unreadable)"
+		if err == nil {
+			var list []string
+			for _, serverName := range parseConf(string(content)).ServerNames {
+				list = append(list, serverName.Name)
+			}
+			names = strings.Join(list, " ")
+			if names == "" {
+				names = "(no server_name)"
+			}
+		}
+
+		fmt.Printf("  %-10s %-30s %s\n", label, name, names)
+		count++
+	}
+
+	if count == 0 {
+		fmt.Printf("✓ No configs changed since %s\n", ref)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// isGlobPattern reports whether a configured directory is really a pattern
+func isGlobPattern(p string) bool {
+	return strings.ContainsAny(p, "*?[")
+}
+
+// globFiles expands pattern doublestar-style: "*", "?" and "[...]" match
+// within one path segment as with filepath.Match, and a "**" segment matches
+// any number of directories, e.g. /etc/nginx/**/sites/*.conf
+func globFiles(pattern string) ([]string, error) {
+	sep := string(filepath.Separator)
+	segments := strings.Split(filepath.Clean(pattern), sep)
+
+	// Walk from the deepest directory that has no wildcards in it
+	i := 0
+	for i < len(segments) && !isGlobPattern(segments[i]) {
+		i++
+	}
+	root := strings.Join(segments[:i], sep)
+	if root == "" {
+		root = "."
+		if filepath.IsAbs(pattern) {
+			root = sep
+		}
+	}
+	rest := segments[i:]
+
+	var matches []string
+	err := filepath.WalkDir(root, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if file == root && os.IsNotExist(err) {
+				return filepath.SkipAll
+			}
+			// Unreadable subdirectories just don't contribute matches
+			if d != nil && d.IsDir() && file != root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, file)
+		if err != nil {
+			return err
+		}
+		if matchSegments(rest, strings.Split(rel, sep)) {
+			matches = append(matches, file)
+		}
+		return nil
+	})
+	return matches, err
+}
+
+// matchSegments matches path segments against pattern segments, with "**"
+// standing for zero or more segments
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
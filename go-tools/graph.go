@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// includeEdge is one resolved or unresolved include between two files
+type includeEdge struct {
+	from, to   string
+	unresolved bool
+}
+
+// includeGraph follows the includes of every root file recursively and
+// returns the edges, each file visited once so cycles terminate. Relative
+// includes are resolved against prefix.
+func includeGraph(roots []string, prefix string) []includeEdge {
+	var edges []includeEdge
+	visited := map[string]bool{}
+
+	var visit func(path string)
+	visit = func(path string) {
+		if visited[path] {
+			return
+		}
+		visited[path] = true
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return
+		}
+		for _, written := range parseConf(string(content)).Includes {
+			include := resolveIncludeFrom(prefix, written)
+			if !include.Resolved {
+				edges = append(edges, includeEdge{path, written, true})
+				continue
+			}
+			for _, file := range include.Files {
+				edges = append(edges, includeEdge{path, file, false})
+				visit(file)
+			}
+		}
+	}
+
+	for _, root := range roots {
+		visit(root)
+	}
+	return edges
+}
+
+// dotQuote quotes s as a DOT identifier
+func dotQuote(s string) string {
+	return `"` + strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), `"`, `\"`) + `"`
+}
+
+// 47. Graph Functionality - Which configs include which, as Graphviz DOT
+func handleGraph(args []string) {
+	fs := flag.NewFlagSet("graph", flag.ExitOnError)
+	format := fs.String("format", "dot", "output format: dot")
+	prefix := fs.String("prefix", "", "nginx prefix relative includes are resolved from, like nginx -p (default: the parent of NGINX_DIR)")
+	parseFlags(fs, args)
+
+	if *format != "dot" {
+		fail(ErrInvalid, "Error: unknown format %q, only dot is supported", *format)
+	}
+
+	files, errs := scanConfigs()
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	var roots []string
+	disabled := map[string]bool{}
+	for _, file := range files {
+		roots = append(roots, file.Path)
+		disabled[file.Path] = !file.enabled()
+	}
+	sort.Strings(roots)
+	if *prefix == "" {
+		*prefix = filepath.Dir(cfg.NginxDir)
+	}
+	edges := includeGraph(roots, *prefix)
+
+	fmt.Println("digraph includes {")
+	fmt.Println("  rankdir=LR;")
+	fmt.Println("  node [shape=box];")
+	for _, root := range roots {
+		style := ""
+		if disabled[root] {
+			style = ", style=dashed"
+		}
+		fmt.Printf("  %s [label=%s%s];\n", dotQuote(root), dotQuote(confFilename(root)), style)
+	}
+	for _, edge := range edges {
+		if edge.unresolved {
+			// Unresolved includes get a node of their own, keyed by file so
+			// the same pattern in two files doesn't merge
+			node := dotQuote(edge.from + " -> " + edge.to)
+			fmt.Printf("  %s [label=%s, color=red, fontcolor=red, shape=note];\n", node, dotQuote(edge.to+" (unresolved)"))
+			fmt.Printf("  %s -> %s [color=red, style=dashed];\n", dotQuote(edge.from), node)
+			continue
+		}
+		fmt.Printf("  %s -> %s;\n", dotQuote(edge.from), dotQuote(edge.to))
+	}
+	fmt.Println("}")
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// healthPollInterval is how often waitHealthy re-checks
+const healthPollInterval = 500 * time.Millisecond
+
+// checkHealth reports why nginx isn't healthy yet, or nil once every
+// service is active and healthURL (if set) answers without a server error
+func checkHealth(host string, services []string, healthURL string, client *http.Client) error {
+	for _, service := range services {
+		output, err := nginxCommand(host, "systemctl", "is-active", service).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("%s is %s", service, strings.TrimSpace(string(output)))
+		}
+	}
+
+	if healthURL == "" {
+		return nil
+	}
+	resp, err := client.Get(healthURL)
+	if err != nil {
+		return fmt.Errorf("health check failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("health check returned %s", resp.Status)
+	}
+	return nil
+}
+
+// waitHealthy polls checkHealth until it passes or timeout runs out, since
+// systemctl reload returns before the new workers are up
+func waitHealthy(host string, services []string, healthURL string, timeout time.Duration) error {
+	if simulate {
+		simulated("wait for %v to be healthy", services)
+		return nil
+	}
+	client := &http.Client{Timeout: 5 * time.Second}
+	deadline := time.Now().Add(timeout)
+
+	for {
+		err := checkHealth(host, services, healthURL, client)
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("not healthy after %s: %v", timeout, err)
+		}
+		time.Sleep(healthPollInterval)
+	}
+}
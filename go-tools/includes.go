@@ -0,0 +1,39 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Include is one include directive and what it points at
+type Include struct {
+	Path     string   `json:"path"`     // As written in the config
+	Resolved bool     `json:"resolved"` // At least one existing file matches
+	Files    []string `json:"files"`    // Existing files it expands to
+}
+
+// resolveInclude expands an include the way nginx does: relative paths are
+// taken from the nginx prefix and wildcards are matched with glob(3) rules.
+// Includes of a config fetched from an ssh:// NGINX_DIR point at the
+// remote host's files, which were never fetched, so they stay unresolved.
+func resolveInclude(path string) Include {
+	if isFetched(cfg.NginxDir) {
+		return Include{Path: path}
+	}
+	return resolveIncludeFrom(filepath.Dir(cfg.NginxDir), path)
+}
+
+// resolveIncludeFrom is resolveInclude with an explicit nginx prefix
+func resolveIncludeFrom(prefix, path string) Include {
+	include := Include{Path: path}
+
+	pattern := resolveFrom(prefix, path)
+	if isGlobPattern(pattern) {
+		include.Files, _ = filepath.Glob(pattern)
+	} else if _, err := os.Stat(pattern); err == nil {
+		include.Files = []string{pattern}
+	}
+
+	include.Resolved = len(include.Files) > 0
+	return include
+}
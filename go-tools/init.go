@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// envTemplate is the .env written by init. It lists every key
+// applySetting recognizes with its default, so keep the two in sync.
+const envTemplate = `# Configuration for conf-mover, written by "conf-mover init"
+#
+# Files are read in this order, later ones overriding earlier ones:
+#   /etc/conf-mover/config.env          (system-wide defaults)
+#   ~/.config/conf-mover/config.env     (per-user overrides)
+#   ./.env                              (local overrides)
+# Set CONF_MOVER_CONFIG to a ':'-separated list of paths to use instead.
+# Commented-out keys show their default.
+
+# Live nginx configs. May also be a glob such as /etc/nginx/**/sites/*.conf,
+# in which case it is read-only: list and the reporting commands work,
+# moves don't.
+NGINX_DIR=/etc/nginx/conf.d
+
+# Where disabled configs are kept
+BACKUP_DIR=/home/manager-bkp
+
+# Either may be another host's directory, e.g. ssh://deploy@web1/etc/nginx/conf.d,
+# fetched with the ssh client for list only. Keys must work without a prompt.
+
+# Bookkeeping for conf-mover itself (defaults to $BACKUP_DIR/state.json)
+# STATE_FILE=/home/manager-bkp/state.json
+
+# Where snapshot tarballs go (defaults to $BACKUP_DIR/snapshots)
+# SNAPSHOT_DIR=/home/manager-bkp/snapshots
+
+# Previous versions of each config, one directory per file (defaults to
+# $BACKUP_DIR/versions)
+# VERSIONS_DIR=/home/manager-bkp/versions
+
+# systemd services reload acts on, comma-separated
+# SERVICE_NAMES=nginx
+
+# Configs larger than this are listed but not parsed (K, M or G suffix)
+# MAX_FILE_SIZE=10MB
+
+# How enable places a config in NGINX_DIR: move, copy or symlink.
+# With copy or symlink the backup directory stays the source of truth and
+# disable removes the live file instead of moving it back.
+# ENABLE_MODE=move
+
+# Every command that changes configs, reloads or pins is appended to this
+# log. It is rotated to audit.log.1, audit.log.2, ... once it would grow
+# past AUDIT_MAX_SIZE, keeping AUDIT_KEEP rotated files. AUDIT_LOG defaults
+# to $BACKUP_DIR/audit.log.
+# AUDIT_LOG=/home/manager-bkp/audit.log
+# AUDIT_MAX_SIZE=10MB
+# AUDIT_KEEP=5
+`
+
+// 37. Init Functionality - Scaffold a commented .env
+func handleInit(args []string) {
+	fs := flag.NewFlagSet("init", flag.ExitOnError)
+	force := fs.Bool("force", false, "overwrite an existing file")
+	positional := parseFlags(fs, args)
+
+	path := ".env"
+	switch len(positional) {
+	case 0:
+	case 1:
+		path = positional[0]
+	default:
+		fail(ErrUsage, "Usage: ./conf-mover init [path] [--force]")
+	}
+
+	if _, err := os.Stat(path); err == nil && !*force {
+		fail(ErrDstExists, "Error: %s already exists (use --force to overwrite)", path)
+	}
+
+	if err := writeFileAtomic(path, []byte(envTemplate)); err != nil {
+		fail(codeOf(err), "Error writing %s: %v", path, err)
+	}
+	fmt.Printf("✓ Wrote %s, edit NGINX_DIR and BACKUP_DIR to match this host\n", path)
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// lintRule is one hygiene or structure check. fix, when set, returns the content with
+// the problem corrected.
+type lintRule struct {
+	check func(content []byte) []string
+	fix   func(content []byte) []byte
+}
+
+// lintRules run in order. Each sees the content as corrected by the earlier
+// rules, so e.g. line endings are checked on the UTF-8 form of a UTF-16 file.
+var lintRules = []lintRule{
+	{checkEncoding, fixEncoding},
+	{checkLineEndings, fixLineEndings},
+	{checkTrailingWhitespace, fixTrailingWhitespace},
+	{checkIndentation, fixIndentation},
+	{checkTrailingNewline, fixTrailingNewline},
+	{checkDirectiveContexts, nil},
+}
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+func checkEncoding(content []byte) []string {
+	switch {
+	case bytes.HasPrefix(content, bomUTF8):
+		return []string{"UTF-8 byte order mark present"}
+	case bytes.HasPrefix(content, bomUTF16LE), bytes.HasPrefix(content, bomUTF16BE):
+		return []string{"UTF-16 encoded, nginx expects UTF-8"}
+	case !utf8.Valid(content):
+		return []string{"not valid UTF-8"}
+	}
+	return nil
+}
+
+func fixEncoding(content []byte) []byte {
+	if bytes.HasPrefix(content, bomUTF8) {
+		return content[len(bomUTF8):]
+	}
+
+	bigEndian := bytes.HasPrefix(content, bomUTF16BE)
+	if !bigEndian && !bytes.HasPrefix(content, bomUTF16LE) {
+		// Some other encoding, we can't know how to convert it
+		return content
+	}
+
+	units := make([]uint16, 0, len(content)/2)
+	for i := 2; i+1 < len(content); i += 2 {
+		if bigEndian {
+			units = append(units, uint16(content[i])<<8|uint16(content[i+1]))
+		} else {
+			units = append(units, uint16(content[i+1])<<8|uint16(content[i]))
+		}
+	}
+	return []byte(string(utf16.Decode(units)))
+}
+
+func checkLineEndings(content []byte) []string {
+	if bytes.Contains(content, []byte("\r\n")) {
+		return []string{"CRLF line endings"}
+	}
+	return nil
+}
+
+func fixLineEndings(content []byte) []byte {
+	return bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+}
+
+func checkTrailingWhitespace(content []byte) []string {
+	count, first := 0, 0
+	for i, line := range bytes.Split(content, []byte("\n")) {
+		if len(line) > 0 && (line[len(line)-1] == ' ' || line[len(line)-1] == '\t') {
+			if count == 0 {
+				first = i + 1
+			}
+			count++
+		}
+	}
+	if count > 0 {
+		return []string{fmt.Sprintf("trailing whitespace on %d line(s), first at line %d", count, first)}
+	}
+	return nil
+}
+
+func fixTrailingWhitespace(content []byte) []byte {
+	lines := bytes.Split(content, []byte("\n"))
+	for i, line := range lines {
+		lines[i] = bytes.TrimRight(line, " \t")
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
+
+// indentWidth is how many spaces a tab stands for when indentation is
+// normalized
+const indentWidth = 4
+
+// indentationCounts counts the lines indented with tabs and with spaces
+func indentationCounts(content []byte) (tabs, spaces int) {
+	for _, line := range bytes.Split(content, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		switch line[0] {
+		case '\t':
+			tabs++
+		case ' ':
+			spaces++
+		}
+	}
+	return tabs, spaces
+}
+
+func checkIndentation(content []byte) []string {
+	tabs, spaces := indentationCounts(content)
+	if tabs > 0 && spaces > 0 {
+		return []string{fmt.Sprintf("mixes tabs (%d line(s)) and spaces (%d line(s)) for indentation", tabs, spaces)}
+	}
+	return nil
+}
+
+// fixIndentation rewrites the indentation of every line in whichever style
+// most lines already use
+func fixIndentation(content []byte) []byte {
+	tabs, spaces := indentationCounts(content)
+	if tabs == 0 || spaces == 0 {
+		return content
+	}
+
+	lines := bytes.Split(content, []byte("\n"))
+	for i, line := range lines {
+		body := bytes.TrimLeft(line, " \t")
+		if len(body) == 0 {
+			continue
+		}
+
+		// Width of the indentation, with tabs advancing to the next stop
+		width := 0
+		for _, c := range line[:len(line)-len(body)] {
+			if c == '\t' {
+				width += indentWidth - width%indentWidth
+			} else {
+				width++
+			}
+		}
+
+		var indent []byte
+		if tabs > spaces {
+			indent = append(bytes.Repeat([]byte("\t"), width/indentWidth), bytes.Repeat([]byte(" "), width%indentWidth)...)
+		} else {
+			indent = bytes.Repeat([]byte(" "), width)
+		}
+		lines[i] = append(indent, body...)
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
+
+func checkTrailingNewline(content []byte) []string {
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		return []string{"missing trailing newline"}
+	}
+	return nil
+}
+
+func fixTrailingNewline(content []byte) []byte {
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		return append(content, '\n')
+	}
+	return content
+}
+
+// lintFile reports the problems rules find in one file, fixing what it can when fix is
+// set. It returns the problems that remain.
+func lintFile(path string, rules []lintRule, fix bool) ([]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	fixed := content
+	for _, rule := range rules {
+		found := rule.check(fixed)
+		if len(found) > 0 && rule.fix != nil {
+			fixed = rule.fix(fixed)
+			if fix {
+				found = rule.check(fixed)
+			}
+		}
+		problems = append(problems, found...)
+	}
+
+	if fix && !bytes.Equal(fixed, content) {
+		if err := writeFileAtomic(path, fixed); err != nil {
+			return problems, err
+		}
+		fmt.Printf("✓ %s: fixed\n", filepath.Base(path))
+	}
+	return problems, nil
+}
+
+// writeFileAtomic replaces path with data, keeping its permissions
+func writeFileAtomic(path string, data []byte) error {
+	if simulate {
+		simulated("write %s (%d bytes)", path, len(data))
+		return nil
+	}
+
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// 12. Lint Functionality - Config file hygiene
+func handleLint(args []string) {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	fix := fs.Bool("fix", false, "rewrite files to correct fixable problems")
+	deprecated := fs.Bool("deprecations", false, "also flag deprecated directives")
+	positional := parseFlags(fs, args)
+
+	rules := lintRules
+	if *deprecated {
+		rules = append(rules[:len(rules):len(rules)], lintRule{checkDeprecations, nil})
+	}
+
+	var paths []string
+	if len(positional) > 0 {
+		for _, name := range positional {
+			path, err := findConf(name)
+			if err != nil {
+				fail(codeOf(err), "Error: %v", err)
+			}
+			paths = append(paths, path)
+		}
+	} else {
+		files, errs := scanConfigs()
+		for _, err := range errs {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		for _, file := range files {
+			paths = append(paths, filepath.Join(file.CurrentDir, file.Filename))
+		}
+	}
+
+	count := 0
+	for _, path := range paths {
+		problems, err := lintFile(path, rules, *fix)
+		if err != nil {
+			fmt.Printf("❌ %s: %v\n", filepath.Base(path), err)
+			count++
+			continue
+		}
+		for _, problem := range problems {
+			fmt.Printf("⚠ %s: %s\n", filepath.Base(path), problem)
+		}
+		count += len(problems)
+	}
+
+	if count > 0 {
+		fmt.Printf("%d warning(s)\n", count)
+		exit(1)
+	}
+	fmt.Println("✓ No problems found")
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// logLine is one line read from a followed log, tagged with its source
+type logLine struct {
+	source string
+	text   string
+}
+
+// logBlockSize is how much of a log lastLines reads at a time
+const logBlockSize = 64 * 1024
+
+// lastLines returns up to n trailing lines of the file and the offset where
+// following should continue. Logs can be many GB, so it reads backwards from
+// the end only as far as it needs to.
+func lastLines(path string, n int) ([]string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+	size := info.Size()
+
+	// n lines need n line breaks before the trailing ones, the first ending
+	// the line before them, unless the start of the file comes first
+	var tail []byte
+	start := size
+	for start > 0 && bytes.Count(bytes.TrimRight(tail, "\n"), []byte("\n")) < n {
+		block := int64(logBlockSize)
+		if start < block {
+			block = start
+		}
+		start -= block
+
+		buf := make([]byte, block)
+		if _, err := f.ReadAt(buf, start); err != nil {
+			return nil, 0, err
+		}
+		tail = append(buf, tail...)
+	}
+
+	text := strings.TrimRight(string(tail), "\n")
+	if text == "" {
+		return nil, size, nil
+	}
+	lines := strings.Split(text, "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines, size, nil
+}
+
+// followLog polls path for appended data and sends each complete line to out.
+// A file that shrinks is assumed to have been rotated and is re-read from
+// the start.
+func followLog(path string, offset int64, out chan<- logLine) {
+	source := filepath.Base(path)
+	var partial []byte
+
+	for {
+		time.Sleep(500 * time.Millisecond)
+
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if info.Size() < offset {
+			offset = 0
+			partial = nil
+		}
+		if info.Size() == offset {
+			continue
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		f.Seek(offset, io.SeekStart)
+		data, _ := io.ReadAll(f)
+		f.Close()
+		offset += int64(len(data))
+
+		partial = append(partial, data...)
+		for {
+			i := bytes.IndexByte(partial, '\n')
+			if i < 0 {
+				break
+			}
+			out <- logLine{source: source, text: string(partial[:i])}
+			partial = partial[i+1:]
+		}
+	}
+}
+
+// 6. Logs Functionality - Tail the logs a vhost writes to
+func handleLogs(args []string) {
+	fs := flag.NewFlagSet("logs", flag.ExitOnError)
+	lines := fs.Int("lines", 10, "number of existing lines to show before following")
+	positional := parseFlags(fs, args)
+
+	if len(positional) != 1 {
+		fail(ErrUsage, "Usage: ./conf-mover logs [filename] [--lines=N]")
+	}
+	if *lines < 0 {
+		fail(ErrInvalid, "Error: --lines can't be negative")
+	}
+
+	path, err := findConf(positional[0])
+	if err != nil {
+		fail(codeOf(err), "Error: %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		fail(codeOf(err), "Error reading config: %v", err)
+	}
+
+	info := parseConf(string(content))
+	if len(info.LogFiles) == 0 {
+		fail(ErrInvalid, "Error: %s declares no access_log or error_log files", filepath.Base(path))
+	}
+
+	out := make(chan logLine)
+	for _, logFile := range info.LogFiles {
+		existing, offset, err := lastLines(logFile, *lines)
+		if err != nil {
+			fmt.Printf("Warning: cannot read %s: %v\n", logFile, err)
+		}
+		for _, line := range existing {
+			fmt.Printf("[%s] %s\n", filepath.Base(logFile), line)
+		}
+		go followLog(logFile, offset, out)
+	}
+
+	// Interleave lines from every log as they arrive, until interrupted
+	for line := range out {
+		fmt.Printf("[%s] %s\n", line.source, line.text)
+	}
+}
@@ -1,226 +1,1474 @@
 package main
 
 import (
+	"bytes"
+	"compress/gzip"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"text/template"
+	"time"
 )
 
 // Config struct
 type Config struct {
-	NginxDir  string
-	BackupDir string
+	NginxDir    string
+	BackupDir   string
+	StateFile   string   // Where conf-mover keeps its own bookkeeping
+	SnapshotDir string   // Where snapshot tarballs are written
+	VersionsDir string   // Where previous versions of each config are kept
+	MaxFileSize int64    // Configs larger than this many bytes aren't parsed
+	Services    []string // systemd services reload acts on
+	IncludeGz   bool     // Directory scans also pick up .conf.gz files
+	EnableMode  string   // How enabling places a config: move, copy or symlink
+	ScanLimit   int      // Stop scanning after this many files, 0 for all
+
+	AuditLog     string // Where mutating commands are recorded
+	AuditMaxSize int64  // The audit log is rotated when it would grow past this
+	AuditKeep    int    // How many rotated audit logs to keep
 }
 
 // FileData represents the JSON output for the list command
 type FileData struct {
-	Filename   string `json:"filename"`
-	ServerName string `json:"server_name"`
-	CurrentDir string `json:"current_dir"` // Full path where file is located
+	Filename     string            `json:"filename"`
+	ServerName   string            `json:"server_name"`
+	ServerNames  []ServerName      `json:"server_names"` // Every name, classified
+	CurrentDir   string            `json:"current_dir"`  // Full path where file is located
+	Path         string            `json:"path"`         // Absolute path of the file itself
+	ParseError   string            `json:"parse_error,omitempty"`
+	Skipped      string            `json:"skipped,omitempty"` // Why the file wasn't parsed
+	IsDefault    bool              `json:"is_default"`        // A listen directive has default_server
+	ProxyTargets []string          `json:"proxy_targets"`     // URLs or upstream names from proxy_pass
+	Includes     []Include         `json:"includes"`
+	ModTime      time.Time         `json:"mod_time"`
+	Pinned       bool              `json:"pinned"` // Protected from disable, see pin
+	Tags         map[string]string `json:"tags,omitempty"`
+	ContentHash  string            `json:"content_hash,omitempty"` // SHA256 of the file, with list --with-hash
+	HTTP2Enabled bool              `json:"http2_enabled"`
+	HTTP3Enabled bool              `json:"http3_enabled"` // Listens with quic
+	Warnings     []string          `json:"warnings"`      // Soft problems found while parsing
+
+	active    bool
+	listens   []Listen
+	certs     []string
+	tls       []TLSSetting
+	upstreams []Upstream
+}
+
+// enabled reports whether the file came from the live nginx directory
+func (f FileData) enabled() bool {
+	return f.active
 }
 
 var cfg = Config{}
 
+// configExtensions are the config formats loadEnvFile understands
+var configExtensions = []string{".env", ".yaml", ".yml", ".toml"}
+
+// configSearchPaths returns the config files loadEnv reads, in order.
+// The system-wide file comes first, then the per-user file, then the local
+// .env, so later files override earlier ones. The system and user locations
+// are tried as config.env, config.yaml, config.yml and config.toml. Setting
+// CONF_MOVER_CONFIG to a list of paths (separated by ':') replaces the
+// defaults entirely.
+func configSearchPaths() []string {
+	if override := os.Getenv("CONF_MOVER_CONFIG"); override != "" {
+		return filepath.SplitList(override)
+	}
+
+	dirs := []string{"/etc/conf-mover"}
+	if userDir, err := os.UserConfigDir(); err == nil {
+		dirs = append(dirs, filepath.Join(userDir, "conf-mover"))
+	}
+
+	var paths []string
+	for _, dir := range dirs {
+		for _, ext := range configExtensions {
+			paths = append(paths, filepath.Join(dir, "config"+ext))
+		}
+	}
+	return append(paths, ".env")
+}
+
 func loadEnv() {
 	// Default values
 	cfg.NginxDir = "/etc/nginx/conf.d"
 	cfg.BackupDir = "/home/manager-bkp"
+	cfg.MaxFileSize = 10 << 20
+	cfg.Services = []string{"nginx"}
+	cfg.EnableMode = "move"
+	cfg.AuditMaxSize = 10 << 20
+	cfg.AuditKeep = 5
 
-	// Read .env file
-	data, err := os.ReadFile(".env")
+	for _, path := range configSearchPaths() {
+		loadEnvFile(path)
+	}
+
+	// Derived defaults depend on the final BackupDir
+	if cfg.StateFile == "" {
+		cfg.StateFile = filepath.Join(cfg.BackupDir, "state.json")
+	}
+	if cfg.SnapshotDir == "" {
+		cfg.SnapshotDir = filepath.Join(cfg.BackupDir, "snapshots")
+	}
+	if cfg.VersionsDir == "" {
+		cfg.VersionsDir = filepath.Join(cfg.BackupDir, "versions")
+	}
+	if cfg.AuditLog == "" {
+		cfg.AuditLog = filepath.Join(cfg.BackupDir, "audit.log")
+	}
+}
+
+// loadEnvFile overlays the settings from a single config file onto cfg.
+// The format follows the extension: KEY=VALUE for .env, "key: value" for
+// YAML and "key = value" for TOML. Only flat top-level keys are supported,
+// which is all the settings need; keys are case-insensitive so nginx_dir
+// in YAML sets the same field as NGINX_DIR in .env.
+func loadEnvFile(path string) {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		// If .env is missing, use defaults
+		// If the file is missing, keep what we have so far
 		return
 	}
 
+	separator := "="
+	structured := true
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		separator = ":"
+	case ".toml":
+	default:
+		structured = false
+	}
+
 	lines := strings.Split(string(data), "\n")
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
-		if line == "" || strings.HasPrefix(line, "#") {
+		if line == "" || strings.HasPrefix(line, "#") || line == "---" || strings.HasPrefix(line, "[") {
 			continue
 		}
 
-		parts := strings.SplitN(line, "=", 2)
+		parts := strings.SplitN(line, separator, 2)
 		if len(parts) == 2 {
-			key := strings.TrimSpace(parts[0])
+			key := strings.ToUpper(strings.TrimSpace(parts[0]))
 			value := strings.TrimSpace(parts[1])
-
-			switch key {
-			case "NGINX_DIR":
-				cfg.NginxDir = value
-			case "BACKUP_DIR":
-				cfg.BackupDir = value
+			if structured {
+				value = yamlValue(value)
 			}
+			applySetting(key, value)
 		}
 	}
 }
 
+// yamlValue turns a YAML/TOML scalar into its string value: a quoted string
+// loses its quotes, an unquoted one loses any trailing # comment
+func yamlValue(value string) string {
+	if len(value) > 0 && (value[0] == '"' || value[0] == '\'') {
+		if end := strings.IndexByte(value[1:], value[0]); end >= 0 {
+			return value[1 : end+1]
+		}
+		return value
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = value[:i]
+	}
+	return strings.TrimSpace(value)
+}
+
+// applySetting stores one recognized config key in cfg. New keys also
+// belong in envTemplate (init.go) and .example.env.
+func applySetting(key, value string) {
+	switch key {
+	case "NGINX_DIR":
+		cfg.NginxDir = value
+	case "BACKUP_DIR":
+		cfg.BackupDir = value
+	case "STATE_FILE":
+		cfg.StateFile = value
+	case "SNAPSHOT_DIR":
+		cfg.SnapshotDir = value
+	case "VERSIONS_DIR":
+		cfg.VersionsDir = value
+	case "SERVICE_NAMES":
+		var services stringList
+		services.Set(value)
+		if len(services) > 0 {
+			cfg.Services = services
+		}
+	case "MAX_FILE_SIZE":
+		if size, err := parseSize(value); err == nil {
+			cfg.MaxFileSize = size
+		}
+	case "ENABLE_MODE":
+		if enableModes[value] {
+			cfg.EnableMode = value
+		}
+	case "AUDIT_LOG":
+		cfg.AuditLog = value
+	case "AUDIT_MAX_SIZE":
+		if size, err := parseSize(value); err == nil {
+			cfg.AuditMaxSize = size
+		}
+	case "AUDIT_KEEP":
+		if keep, err := strconv.Atoi(value); err == nil && keep >= 0 {
+			cfg.AuditKeep = keep
+		}
+	}
+}
+
+// enableModes are the accepted ENABLE_MODE values
+var enableModes = map[string]bool{"move": true, "copy": true, "symlink": true}
+
+// parseSize reads a byte count with an optional K, M or G suffix
+// (e.g. "512K", "10MB")
+func parseSize(value string) (int64, error) {
+	value = strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(value)), "B")
+
+	multiplier := int64(1)
+	switch {
+	case strings.HasSuffix(value, "K"):
+		multiplier = 1 << 10
+	case strings.HasSuffix(value, "M"):
+		multiplier = 1 << 20
+	case strings.HasSuffix(value, "G"):
+		multiplier = 1 << 30
+	}
+	if multiplier > 1 {
+		value = value[:len(value)-1]
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+	return n * multiplier, nil
+}
+
+// writesNginxDir lists the commands that create or move files in NginxDir
+var writesNginxDir = map[string]bool{
+	"move": true, "create": true, "apply": true, "rename": true,
+	"enable": true, "disable": true, "resume": true, "deploy": true,
+	"snapshot": true, "edit": true, "generate": true, "undo": true,
+	"merge": true, "split": true, "safe-reload": true,
+}
+
 func main() {
 	loadEnv()
 
+	// Hidden developer flags, accepted anywhere on the command line.
+	// Profiles are written on exit too, so failing runs can be profiled.
+	atExit = append(atExit, startProfiling())
+	defer runAtExit()
+
+	// Prefix failures with their error code, see errcodes.go
+	parseableErrors = takeGlobalFlag("parseable")
+	// Report what mutating commands would do instead of doing it
+	simulate = takeGlobalFlag("simulate")
+
 	if len(os.Args) < 2 {
-		fmt.Println("Usage: ./conf-mover [move|reload|list] ...")
-		os.Exit(1)
+		fail(ErrUsage, "Usage: ./conf-mover [move|reload|list|pending|snapshot|logs|create|apply|metrics|rename|audit|lint|enable|disable|resume|ports|parse|deploy|certs|git-diff|git-status|test-file|edit|tree|stats|drift|generate|changed-since|pin|unpin|undo|tls-audit|merge|split|backup-changed|check|dump|init|check-upstreams|backup-dedupe|audit-log|verify-backups|du|tag|bundle|unbundle|safe-reload|graph|match|unreferenced] ...")
 	}
 
 	command := os.Args[1]
 
+	// A glob NGINX_DIR can be read from but there's nowhere to write to
+	if isGlobPattern(cfg.NginxDir) && writesNginxDir[command] {
+		fail(ErrInvalid, "Error: NGINX_DIR is a glob pattern (%s); %s needs a directory", cfg.NginxDir, command)
+	}
+	// ssh:// directories are read from a local copy, see remote.go
+	fetchRemoteDirs(command)
+
+	recordAction(command, os.Args[2:])
+
 	switch command {
 	case "move":
-		handleMove()
+		handleMove(os.Args[2:])
 	case "reload":
-		handleReload()
+		handleReload(os.Args[2:])
 	case "list":
-		handleList()
+		handleList(os.Args[2:])
+	case "pending":
+		handlePending()
+	case "snapshot":
+		handleSnapshot(os.Args[2:])
+	case "logs":
+		handleLogs(os.Args[2:])
+	case "create":
+		handleCreate(os.Args[2:])
+	case "apply":
+		handleApply(os.Args[2:])
+	case "metrics":
+		handleMetrics()
+	case "rename":
+		handleRename(os.Args[2:])
+	case "audit":
+		handleAudit(os.Args[2:])
+	case "lint":
+		handleLint(os.Args[2:])
+	case "enable":
+		handleEnable(os.Args[2:])
+	case "disable":
+		handleDisable(os.Args[2:])
+	case "resume":
+		handleResume()
+	case "ports":
+		handlePorts()
+	case "parse":
+		handleParse(os.Args[2:])
+	case "deploy":
+		handleDeploy(os.Args[2:])
+	case "certs":
+		handleCerts(os.Args[2:])
+	case "git-diff":
+		handleGitDiff(os.Args[2:])
+	case "git-status":
+		handleGitStatus()
+	case "test-file":
+		handleTestFile(os.Args[2:])
+	case "edit":
+		handleEdit(os.Args[2:])
+	case "tree":
+		handleTree()
+	case "stats":
+		handleStats(os.Args[2:])
+	case "drift":
+		handleDrift(os.Args[2:])
+	case "generate":
+		handleGenerate(os.Args[2:])
+	case "changed-since":
+		handleChangedSince(os.Args[2:])
+	case "pin":
+		handlePin(os.Args[2:], true)
+	case "unpin":
+		handlePin(os.Args[2:], false)
+	case "undo":
+		handleUndo(os.Args[2:])
+	case "tls-audit":
+		handleTLSAudit()
+	case "merge":
+		handleMerge(os.Args[2:])
+	case "split":
+		handleSplit(os.Args[2:])
+	case "backup-changed":
+		handleBackupChanged()
+	case "check":
+		handleCheck(os.Args[2:])
+	case "dump":
+		handleDump(os.Args[2:])
+	case "init":
+		handleInit(os.Args[2:])
+	case "check-upstreams":
+		handleCheckUpstreams(os.Args[2:])
+	case "backup-dedupe":
+		handleBackupDedupe(os.Args[2:])
+	case "audit-log":
+		handleAuditLog(os.Args[2:])
+	case "verify-backups":
+		handleVerifyBackups(os.Args[2:])
+	case "du":
+		handleDU(os.Args[2:])
+	case "tag":
+		handleTag(os.Args[2:])
+	case "bundle":
+		handleBundle(os.Args[2:])
+	case "unbundle":
+		handleUnbundle(os.Args[2:])
+	case "safe-reload":
+		handleSafeReload(os.Args[2:])
+	case "graph":
+		handleGraph(os.Args[2:])
+	case "match":
+		handleMatch(os.Args[2:])
+	case "unreferenced":
+		handleUnreferenced(os.Args[2:])
 	default:
-		fmt.Println("Unknown command. Use: move, reload, or list")
-		os.Exit(1)
+		fail(ErrUsage, "Unknown command. Use: move, reload, list, pending, snapshot, logs, create, apply, metrics, rename, audit, lint, enable, disable, resume, ports, parse, deploy, certs, git-diff, git-status, test-file, edit, tree, stats, drift, generate, changed-since, pin, unpin, undo, tls-audit, merge, split, backup-changed, check, dump, init, check-upstreams, backup-dedupe, audit-log, verify-backups, du, tag, bundle, unbundle, safe-reload, graph, match, or unreferenced")
 	}
 }
 
-// 1. Move Functionality - Quickly enable/disable sites
-func handleMove() {
-	if len(os.Args) != 4 {
-		fmt.Println("Usage: ./conf-mover move [backup|restore] [filename]")
-		os.Exit(1)
-	}
-
-	action := os.Args[2]
-	filename := filepath.Base(os.Args[3])
-	
-	// Ensure filename ends with .conf
-	if !strings.HasSuffix(filename, ".conf") {
-		filename = filename + ".conf"
-	}
-
-	var src, dst string
+// moveConf moves filename between NginxDir and BackupDir. action is
+// "backup" (disable) or "restore" (enable).
+func moveConf(action, filename string) (src, dst string, err error) {
+	filename = confFilename(filename)
 
 	if action == "backup" {
 		// Disable site: move from nginx to backup
 		src = filepath.Join(cfg.NginxDir, filename)
 		dst = filepath.Join(cfg.BackupDir, filename)
-		
+
 		// Ensure backup directory exists
-		if err := os.MkdirAll(cfg.BackupDir, 0755); err != nil {
-			fmt.Printf("Error creating backup directory: %v\n", err)
-			os.Exit(1)
+		if err := ensureDir(cfg.BackupDir); err != nil {
+			return src, dst, fmt.Errorf("creating backup directory: %w", err)
 		}
 	} else if action == "restore" {
 		// Enable site: move from backup to nginx
 		src = filepath.Join(cfg.BackupDir, filename)
 		dst = filepath.Join(cfg.NginxDir, filename)
-		
+
 		// Ensure nginx directory exists
-		if err := os.MkdirAll(filepath.Dir(cfg.NginxDir), 0755); err != nil {
-			fmt.Printf("Error creating nginx directory: %v\n", err)
-			os.Exit(1)
+		if err := ensureDir(filepath.Dir(cfg.NginxDir)); err != nil {
+			return src, dst, fmt.Errorf("creating nginx directory: %w", err)
 		}
 	} else {
-		fmt.Println("Invalid action. Use backup or restore")
-		os.Exit(1)
+		return "", "", withCode(ErrUsage, fmt.Errorf("invalid action %q, use backup or restore", action))
 	}
 
 	// Check if source file exists
-	if _, err := os.Stat(src); os.IsNotExist(err) {
-		fmt.Printf("Error: source file does not exist: %s\n", src)
-		os.Exit(1)
+	if _, err := os.Lstat(src); os.IsNotExist(err) {
+		return src, dst, withCode(ErrSrcMissing, fmt.Errorf("source file does not exist: %s", src))
+	}
+
+	if simulate {
+		simulated("%s %s -> %s (%s mode)", action, src, dst, cfg.EnableMode)
+		return src, dst, nil
 	}
 
-	// Move the file
-	if err := os.Rename(src, dst); err != nil {
-		fmt.Printf("Error moving file: %v\n", err)
-		os.Exit(1)
+	// With copy and symlink modes the backup directory keeps the canonical
+	// file: enabling places a copy or link, disabling just takes it away.
+	// A live link or unchanged copy is taken away whatever the current
+	// mode, since it may have been enabled under another; renaming it over
+	// the canonical file would lose the config.
+	if action == "restore" && cfg.EnableMode != "move" {
+		return src, dst, placeConf(src, dst)
+	}
+	if _, err := os.Lstat(dst); err == nil && action == "backup" && (cfg.EnableMode != "move" || isPlaced(src, dst)) {
+		if err := unplaceConf(src, dst); err != nil {
+			return src, dst, err
+		}
+	} else if err := os.Rename(src, dst); err != nil {
+		return src, dst, fmt.Errorf("moving file: %w", err)
+	}
+
+	// A rename keeps the old modification time, and taking a copy away
+	// leaves the backup as it was; stamp backups with when they were made
+	// so the newest one is the latest disable (see undo)
+	if action == "backup" {
+		now := time.Now()
+		os.Chtimes(dst, now, now)
+	}
+
+	return src, dst, nil
+}
+
+// placeConf enables src by copying or linking it to dst, per ENABLE_MODE
+func placeConf(src, dst string) error {
+	if cfg.EnableMode == "symlink" {
+		return linkConf(src, dst)
+	}
+
+	content, err := os.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("copying file: %w", err)
+	}
+	if err := writeFileAtomic(dst, content); err != nil {
+		return fmt.Errorf("copying file: %w", err)
+	}
+	return nil
+}
+
+// linkConf replaces dst with an absolute symlink to src
+func linkConf(src, dst string) error {
+	abs, err := filepath.Abs(src)
+	if err != nil {
+		return err
+	}
+	os.Remove(dst)
+	if err := os.Symlink(abs, dst); err != nil {
+		return fmt.Errorf("linking file: %w", err)
+	}
+	return nil
+}
+
+// unplaceConf disables a copied or linked config by removing the live
+// file, as long as that loses nothing the backup doesn't also have
+func unplaceConf(live, backup string) error {
+	if info, err := os.Lstat(live); err == nil && info.Mode()&os.ModeSymlink == 0 {
+		liveHash, err := hashFile(live)
+		if err != nil {
+			return err
+		}
+		backupHash, err := hashFile(backup)
+		if err != nil {
+			return err
+		}
+		if liveHash != backupHash {
+			return fmt.Errorf("%s has changed since it was enabled; it differs from %s, so move or merge it by hand", live, backup)
+		}
+	}
+	if err := os.Remove(live); err != nil {
+		return fmt.Errorf("removing file: %w", err)
+	}
+	return nil
+}
+
+// isPlaced reports whether live is a link or an identical copy of backup,
+// as placeConf leaves it
+func isPlaced(live, backup string) bool {
+	info, err := os.Lstat(live)
+	if err != nil {
+		return false
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		return true
+	}
+	liveHash, err := hashFile(live)
+	if err != nil {
+		return false
+	}
+	backupHash, err := hashFile(backup)
+	return err == nil && liveHash == backupHash
+}
+
+// 1. Move Functionality - Quickly enable/disable sites
+func handleMove(args []string) {
+	fs := flag.NewFlagSet("move", flag.ExitOnError)
+	reload := fs.Bool("reload", false, "test and reload nginx after the move")
+	revert := fs.Bool("revert-on-failure", false, "undo the move if the config test fails (implies --reload)")
+	force := fs.Bool("force", false, "back up a pinned config too")
+	positional := parseFlags(fs, args)
+
+	if len(positional) != 2 {
+		fail(ErrUsage, "Usage: ./conf-mover move [backup|restore] [filename] [--reload] [--revert-on-failure] [--force]")
+	}
+
+	filename := confFilename(positional[1])
+	if positional[0] == "backup" {
+		if _, err := mayDisable(filename, *force, false); err != nil {
+			fail(codeOf(err), "Error: %v", err)
+		}
+	}
+	src, dst, err := moveConf(positional[0], filename)
+	if err != nil {
+		fail(codeOf(err), "Error: %v", err)
 	}
 
 	fmt.Printf("Success: %s moved %s -> %s\n", filename, src, dst)
+
+	if *reload || *revert {
+		if err := reloadAfterMove(positional[0], []string{filename}, *revert); err != nil {
+			exit(1)
+		}
+	}
+}
+
+// oppositeAction returns the move that undoes action
+func oppositeAction(action string) string {
+	if action == "backup" {
+		return "restore"
+	}
+	return "backup"
+}
+
+// reloadAfterMove implements --reload for the move commands. The move has
+// already happened, so a failure here must be loud. With revert set, a
+// failing config test moves the files back and nginx is left untouched.
+// Everything is reported as it happens; the returned error summarizes a
+// failure for callers that also record results.
+func reloadAfterMove(action string, filenames []string, revert bool) error {
+	moved := "the file was"
+	if len(filenames) > 1 {
+		moved = "the files were"
+	}
+
+	result := runReload()
+	if !result.TestPassed && revert {
+		fmt.Println(errorLine(result.Code, fmt.Sprintf("❌ %s:\n%s", result.Error, result.TestOutput)))
+		reverted := true
+		for _, filename := range filenames {
+			src, dst, err := moveConf(oppositeAction(action), filename)
+			if err != nil {
+				fmt.Printf("❌ Revert of %s failed: %v\n", filename, err)
+				reverted = false
+				continue
+			}
+			setReenable(filename, time.Time{})
+			fmt.Printf("↩ Reverted: %s moved %s -> %s\n", filename, src, dst)
+		}
+		if !reverted {
+			fmt.Printf("⚠ WARNING: %s moved, nginx was NOT reloaded and the move could not be fully undone\n", moved)
+			return withCode(result.Code, errors.New("config test failed and the move could not be fully reverted"))
+		}
+		fmt.Println("Nginx was not reloaded")
+		return withCode(result.Code, errors.New("config test failed, move reverted"))
+	}
+
+	if err := reportReload(result); err != nil {
+		fmt.Println(errorLine(codeOf(err), fmt.Sprintf("❌ %v", err)))
+		fmt.Printf("⚠ WARNING: %s moved but nginx was NOT reloaded\n", moved)
+		return withCode(result.Code, errors.New(result.Error))
+	}
+	return nil
+}
+
+// confFilename strips any directory from name and ensures it ends with .conf
+func confFilename(name string) string {
+	filename := filepath.Base(name)
+	if !strings.HasSuffix(filename, ".conf") {
+		filename = filename + ".conf"
+	}
+	return filename
+}
+
+// findConf locates a config by name, preferring the enabled copy
+func findConf(name string) (string, error) {
+	filename := confFilename(name)
+	for _, dir := range []string{cfg.NginxDir, cfg.BackupDir} {
+		path := filepath.Join(dir, filename)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", withCode(ErrNotFound, fmt.Errorf("%s not found in %s or %s", filename, cfg.NginxDir, cfg.BackupDir))
+}
+
+// stringList is a flag that can be repeated or given comma-separated values
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+// parseFlags parses args with fs and returns the positional arguments.
+// Unlike fs.Parse it keeps going past the first positional argument, so
+// flags may appear anywhere (e.g. "move backup site.conf --reload").
+func parseFlags(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		fs.Parse(args)
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// takeGlobalFlag removes the boolean flag --name (or -name) from os.Args,
+// wherever it appears, and reports whether it was there
+func takeGlobalFlag(name string) bool {
+	args := os.Args[:1]
+	found := false
+	for _, arg := range os.Args[1:] {
+		if arg == "--"+name || arg == "-"+name {
+			found = true
+			continue
+		}
+		args = append(args, arg)
+	}
+	os.Args = args
+	return found
+}
+
+// nginxCommand builds a command that manages nginx: a local process, or
+// the same command over ssh when host is set
+func nginxCommand(host, name string, args ...string) *exec.Cmd {
+	if host == "" {
+		return exec.Command(name, args...)
+	}
+	// BatchMode fails fast instead of prompting for a password
+	return exec.Command("ssh", append([]string{"-o", "BatchMode=yes", host, "--", name}, args...)...)
+}
+
+// exitCode is the exit status of a failed command, or 1 if it didn't run
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+		return exitErr.ExitCode()
+	}
+	return 1
+}
+
+// testNginx runs nginx's own config test and returns its output
+func testNginx() ([]byte, error) {
+	return testNginxOn("")
+}
+
+// testNginxOn is testNginx on a remote host, or locally for "". Under
+// --simulate it passes without running nginx.
+func testNginxOn(host string) ([]byte, error) {
+	if simulate {
+		if host != "" {
+			simulated("nginx -t on %s", host)
+		} else {
+			simulated("nginx -t")
+		}
+		return []byte("simulated"), nil
+	}
+	return nginxCommand(host, "nginx", "-t").CombinedOutput()
+}
+
+// ReloadResult records what a reload attempt did, for --output=json
+type ReloadResult struct {
+	TestPassed bool            `json:"test_passed"`
+	TestOutput string          `json:"test_output"`
+	Reloaded   bool            `json:"reloaded"` // Every service reloaded
+	Error      string          `json:"error"`
+	Services   []ServiceResult `json:"services"`
+	Healthy    *bool           `json:"healthy,omitempty"` // Only set with --wait
+	Host       string          `json:"host,omitempty"`    // Remote host, with --host
+	ExitCode   int             `json:"exit_code"`         // Of the first failing command
+	Code       errorCode       `json:"code,omitempty"`    // What failed, see errcodes.go
+}
+
+// ServiceResult is the reload outcome for one nginx service
+type ServiceResult struct {
+	Service  string `json:"service"`
+	Reloaded bool   `json:"reloaded"`
+	Output   string `json:"output"`
 }
 
 // 2. Reload Functionality - Apply changes
-func handleReload() {
-	// Test nginx configuration
-	testCmd := exec.Command("nginx", "-t")
-	if output, err := testCmd.CombinedOutput(); err != nil {
-		fmt.Printf("❌ Nginx config test failed:\n%s\n", output)
-		os.Exit(1)
+func handleReload(args []string) {
+	fs := flag.NewFlagSet("reload", flag.ExitOnError)
+	ifChanged := fs.Bool("if-changed", false, "only reload when configs changed since the last reload")
+	backupFirst := fs.Bool("backup-first", false, "snapshot the config directory before testing and reloading")
+	output := fs.String("output", "text", "output format: text or json")
+	var services stringList
+	fs.Var(&services, "service", "systemd service to reload (repeatable, default SERVICE_NAMES or nginx)")
+	wait := fs.Bool("wait", false, "after reloading, wait until nginx is active (and --health-url answers)")
+	healthURL := fs.String("health-url", "", "URL that must answer without a 5xx before --wait succeeds")
+	timeout := fs.Duration("timeout", 30*time.Second, "how long --wait keeps checking")
+	var hosts stringList
+	fs.Var(&hosts, "host", "test and reload on this host over ssh, e.g. user@server (repeatable)")
+	concurrency := fs.Int("concurrency", 10, "with several --host, how many to reload at once")
+	parseFlags(fs, args)
+
+	if len(hosts) > 0 {
+		// Pending changes and snapshots describe this machine's configs
+		if *ifChanged || *backupFirst {
+			fail(ErrInvalid, "Error: --if-changed and --backup-first only apply to local reloads")
+		}
+	}
+	if *concurrency < 1 {
+		fail(ErrInvalid, "Error: --concurrency must be at least 1")
+	}
+
+	if *healthURL != "" {
+		*wait = true
+	}
+
+	if len(services) > 0 {
+		cfg.Services = services
+	}
+
+	// In JSON mode stdout carries only the result object
+	status := os.Stdout
+	if *output == "json" {
+		status = os.Stderr
+	}
+
+	if *ifChanged {
+		changes, err := pendingChanges()
+		if err != nil {
+			fmt.Fprintln(status, errorLine(codeOf(err), fmt.Sprintf("Error checking pending changes: %v", err)))
+			exit(1)
+		}
+		if changes.Empty() {
+			fmt.Fprintln(status, "✓ No changes since last reload, skipping")
+			return
+		}
+	}
+
+	if *backupFirst {
+		path, err := createSnapshot()
+		if err != nil {
+			fmt.Fprintln(status, errorLine(codeOf(err), fmt.Sprintf("❌ Failed to snapshot config directory: %v", err)))
+			exit(1)
+		}
+		fmt.Fprintf(status, "✓ Snapshot saved to %s\n", path)
+	}
+
+	if len(hosts) > 1 {
+		reloadHosts(hosts, *concurrency, *output, *wait, *healthURL, *timeout)
+		return
+	}
+
+	host := ""
+	if len(hosts) == 1 {
+		host = hosts[0]
+	}
+	result := reloadHost(host, *wait, *healthURL, *timeout)
+
+	if *output == "json" {
+		jsonOutput, _ := json.MarshalIndent(result, "", "  ")
+		fmt.Println(string(jsonOutput))
+	} else {
+		printReloadResult(result)
+	}
+	if !result.succeeded() {
+		exit(result.ExitCode)
+	}
+}
+
+// reloadHost reloads one host ("" for this machine) and, with wait set,
+// checks that nginx comes back healthy
+func reloadHost(host string, wait bool, healthURL string, timeout time.Duration) ReloadResult {
+	result := runReloadOn(host)
+	if wait && result.Reloaded {
+		err := waitHealthy(host, cfg.Services, healthURL, timeout)
+		healthy := err == nil
+		result.Healthy = &healthy
+		if err != nil {
+			result.Error = err.Error()
+			result.ExitCode = 1
+			result.Code = ErrHealth
+		}
+	}
+	return result
+}
+
+// succeeded reports whether the reload went through and, if it was
+// checked, nginx is healthy
+func (result ReloadResult) succeeded() bool {
+	return result.Reloaded && (result.Healthy == nil || *result.Healthy)
+}
+
+// printReloadResult is the text form of a reload, health check included
+func printReloadResult(result ReloadResult) {
+	if err := reportReload(result); err != nil {
+		fmt.Println(errorLine(result.Code, fmt.Sprintf("❌ %v", err)))
+		return
+	}
+	if result.Healthy == nil {
+		return
+	}
+	if *result.Healthy {
+		fmt.Println("✓ Nginx is up and healthy")
+	} else {
+		fmt.Println(errorLine(result.Code, fmt.Sprintf("❌ Nginx reloaded but is %s", result.Error)))
+	}
+}
+
+// reloadHosts reloads several remote hosts, at most concurrency at a time,
+// and reports the results in the order the hosts were given
+func reloadHosts(hosts []string, concurrency int, output string, wait bool, healthURL string, timeout time.Duration) {
+	results := make([]ReloadResult, len(hosts))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, host := range hosts {
+		wg.Add(1)
+		go func(i int, host string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = reloadHost(host, wait, healthURL, timeout)
+		}(i, host)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, result := range results {
+		if result.succeeded() {
+			succeeded++
+		}
 	}
 
-	fmt.Println("✓ Nginx configuration test passed")
+	if output == "json" {
+		jsonOutput, _ := json.MarshalIndent(results, "", "  ")
+		fmt.Println(string(jsonOutput))
+	} else {
+		for _, result := range results {
+			fmt.Printf("==> %s\n", result.Host)
+			printReloadResult(result)
+		}
+		fmt.Printf("Summary: %d of %d hosts reloaded\n", succeeded, len(hosts))
+	}
+
+	if succeeded < len(hosts) {
+		exit(1)
+	}
+}
+
+// runReload tests the configuration and, if it passes, reloads every
+// configured nginx service. The test runs once, against the default nginx
+// config; all services are attempted even if one fails.
+func runReload() ReloadResult {
+	return runReloadOn("")
+}
+
+// runReloadOn is runReload on a remote host, or locally for ""
+func runReloadOn(host string) ReloadResult {
+	result := ReloadResult{Host: host}
+
+	if simulate {
+		return simulatedReload(host)
+	}
+
+	// Test nginx configuration
+	output, err := testNginxOn(host)
+	result.TestOutput = string(output)
+	if err != nil {
+		result.Error = "Nginx config test failed"
+		result.ExitCode = exitCode(err)
+		result.Code = ErrNginxTest
+		return result
+	}
+	result.TestPassed = true
 
 	// Reload nginx
-	reloadCmd := exec.Command("systemctl", "reload", "nginx")
-	if output, err := reloadCmd.CombinedOutput(); err != nil {
-		fmt.Printf("❌ Failed to reload nginx:\n%s\n", output)
-		os.Exit(1)
+	var failures []string
+	for _, service := range cfg.Services {
+		reloadCmd := nginxCommand(host, "systemctl", "reload", service)
+		output, err := reloadCmd.CombinedOutput()
+		result.Services = append(result.Services, ServiceResult{
+			Service:  service,
+			Reloaded: err == nil,
+			Output:   string(output),
+		})
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("Failed to reload %s:\n%s", service, output))
+			if result.ExitCode == 0 {
+				result.ExitCode = exitCode(err)
+			}
+		}
 	}
+	if len(failures) > 0 {
+		result.Error = strings.Join(failures, "\n")
+		result.Code = ErrReload
+		return result
+	}
+	result.Reloaded = true
 
-	fmt.Println("✓ Nginx reloaded successfully")
+	// Remember what nginx just loaded so pending can diff against it; a
+	// remote host's configs aren't the ones pending looks at
+	if host != "" {
+		return result
+	}
+	if err := recordReloadSnapshot(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not record reload snapshot: %v\n", err)
+	}
+	return result
 }
 
-// 3. List Functionality - Show current state
-func handleList() {
+// reportReload prints the successful steps of a reload and returns an
+// error describing whatever failed
+func reportReload(result ReloadResult) error {
+	where := ""
+	if result.Host != "" {
+		where = " on " + result.Host
+	}
+
+	if !result.TestPassed {
+		return withCode(result.Code, fmt.Errorf("%s%s:\n%s", result.Error, where, result.TestOutput))
+	}
+	fmt.Printf("✓ Nginx configuration test passed%s\n", where)
+
+	for _, service := range result.Services {
+		if !service.Reloaded {
+			continue
+		}
+		if len(result.Services) == 1 && service.Service == "nginx" {
+			fmt.Printf("✓ Nginx reloaded successfully%s\n", where)
+		} else {
+			fmt.Printf("✓ Reloaded %s%s\n", service.Service, where)
+		}
+	}
+
+	if !result.Reloaded {
+		return withCode(result.Code, errors.New(result.Error))
+	}
+	return nil
+}
+
+// reloadNginx is runReload for interactive use, printing each step
+func reloadNginx() error {
+	return reportReload(runReload())
+}
+
+// exitPartialFailure is returned by list when some files could not be read
+const exitPartialFailure = 2
+
+// confPaths returns the config files in source, which is either a
+// directory (its .conf files) or a glob pattern (every file it matches)
+func confPaths(source string) ([]string, error) {
+	if isGlobPattern(source) {
+		return globFiles(source)
+	}
+
+	entries, err := os.ReadDir(source)
+	if os.IsNotExist(err) {
+		// Directory might not exist, skip silently
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if !strings.HasSuffix(entry.Name(), ".conf") && !(cfg.IncludeGz && strings.HasSuffix(entry.Name(), ".conf.gz")) {
+			continue
+		}
+		paths = append(paths, filepath.Join(source, entry.Name()))
+	}
+	return paths, nil
+}
+
+// scanFile parses one config. A file that can't be read is still returned,
+// with the reason in ParseError.
+func scanFile(fullPath string, enabled bool) FileData {
+	dir := filepath.Dir(fullPath)
+	filename := strings.TrimSuffix(filepath.Base(fullPath), ".gz")
+	if abs, err := filepath.Abs(fullPath); err == nil {
+		fullPath = abs
+	}
+
+	info := ConfInfo{ServerName: "unknown"}
+	parseError, skipped := "", ""
+	var modTime time.Time
+
+	stat, err := os.Stat(fullPath)
+	if err == nil {
+		modTime = stat.ModTime()
+	}
+
+	// Don't let a runaway file pull gigabytes into memory
+	if err == nil && stat.Size() > cfg.MaxFileSize {
+		skipped = "too_large"
+	} else if strings.HasSuffix(fullPath, ".gz") {
+		content, err := readGzip(fullPath, cfg.MaxFileSize)
+		if err == errTooLarge {
+			skipped = "too_large"
+		} else if err == nil {
+			info = parseConf(string(content))
+		} else {
+			parseError = err.Error()
+		}
+	} else {
+		// Read file content to extract server_name
+		content, err := os.ReadFile(fullPath)
+		if err == nil {
+			info = parseConf(string(content))
+		} else {
+			parseError = err.Error()
+		}
+	}
+
+	var includes []Include
+	for _, path := range info.Includes {
+		includes = append(includes, resolveInclude(path))
+	}
+
+	return FileData{
+		Filename:     filename,
+		ServerName:   info.ServerName,
+		ServerNames:  info.ServerNames,
+		CurrentDir:   dir, // This tells us where the file is located
+		Path:         fullPath,
+		ParseError:   parseError,
+		Skipped:      skipped,
+		IsDefault:    info.IsDefault(),
+		ProxyTargets: info.ProxyPasses,
+		Includes:     includes,
+		ModTime:      modTime,
+		Warnings:     info.Warnings,
+		HTTP2Enabled: info.HTTP2,
+		HTTP3Enabled: info.HTTP3,
+		active:       enabled,
+		listens:      info.Listens,
+		certs:        info.Certs,
+		tls:          info.TLS,
+		upstreams:    info.Upstreams,
+	}
+}
+
+// listTemplate parses the template for list --output=template, given
+// inline or as a file. It is executed once with the whole []FileData, so
+// it can range over the files and summarize them too.
+func listTemplate(text, file string) (*template.Template, error) {
+	name := "template"
+	switch {
+	case text != "" && file != "":
+		return nil, errors.New("use either --template or --template-file, not both")
+	case file != "":
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		text, name = string(content), filepath.Base(file)
+	case text == "":
+		return nil, errors.New("--output=template needs --template or --template-file")
+	}
+
+	tmpl, err := template.New(name).Funcs(template.FuncMap{
+		"join":    strings.Join,
+		"enabled": FileData.enabled,
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// groupByServerName maps each server name to the files that declare it.
+// Files without a server_name are grouped under their placeholder name,
+// e.g. "no_server_name".
+func groupByServerName(files []FileData) map[string][]FileData {
+	groups := map[string][]FileData{}
+	for _, file := range files {
+		if len(file.ServerNames) == 0 {
+			groups[file.ServerName] = append(groups[file.ServerName], file)
+			continue
+		}
+		seen := map[string]bool{}
+		for _, name := range file.ServerNames {
+			if !seen[name.Name] {
+				seen[name.Name] = true
+				groups[name.Name] = append(groups[name.Name], file)
+			}
+		}
+	}
+	return groups
+}
+
+// distinctServerNames returns every exact and wildcard name, sorted
+func distinctServerNames(files []FileData) []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, file := range files {
+		for _, name := range file.ServerNames {
+			if (name.Type == "exact" || name.Type == "wildcard") && !seen[name.Name] {
+				seen[name.Name] = true
+				names = append(names, name.Name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// printFileTable is the human-readable form of list
+func printFileTable(files []FileData, relative bool) {
+	now := time.Now()
+	row := "%-30s %-8s %-30s %s\n"
+	fmt.Printf(row, "FILE", "STATUS", "SERVER_NAME", "MODIFIED")
+	for _, file := range files {
+		status := "enabled"
+		if !file.enabled() {
+			status = "disabled"
+		}
+
+		modified := "-"
+		if !file.ModTime.IsZero() {
+			if relative {
+				modified = relativeTime(file.ModTime, now)
+			} else {
+				modified = file.ModTime.Format(time.RFC3339)
+			}
+		}
+		fmt.Printf(row, file.Filename, status, file.ServerName, modified)
+	}
+}
+
+// relativeTime renders t as a rough age, e.g. "3 days ago"
+func relativeTime(t, now time.Time) string {
+	age := now.Sub(t)
+	unit := func(n int, name string) string {
+		if n == 1 {
+			return fmt.Sprintf("1 %s ago", name)
+		}
+		return fmt.Sprintf("%d %ss ago", n, name)
+	}
+
+	switch {
+	case age < 0:
+		return "in the future"
+	case age < time.Minute:
+		return "just now"
+	case age < time.Hour:
+		return unit(int(age/time.Minute), "minute")
+	case age < 24*time.Hour:
+		return unit(int(age/time.Hour), "hour")
+	case age < 30*24*time.Hour:
+		return unit(int(age/(24*time.Hour)), "day")
+	case age < 365*24*time.Hour:
+		return unit(int(age/(30*24*time.Hour)), "month")
+	default:
+		return unit(int(age/(365*24*time.Hour)), "year")
+	}
+}
+
+// errTooLarge is returned by readGzip when the content exceeds its limit
+var errTooLarge = errors.New("file too large")
+
+// readGzip decompresses a gzipped config, refusing to inflate more than
+// limit bytes so a small archive can't expand into gigabytes
+func readGzip(path string, limit int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	defer gz.Close()
+
+	content, err := io.ReadAll(io.LimitReader(gz, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if int64(len(content)) > limit {
+		return nil, errTooLarge
+	}
+	return content, nil
+}
+
+// scanConfigs parses the active sites followed by the disabled ones,
+// returning any directory-level errors alongside the results
+func scanConfigs() ([]FileData, []error) {
 	var files []FileData
+	var errs []error
 
-	// Helper to process a directory
-	processDir := func(dir string) {
-		entries, err := os.ReadDir(dir)
+	for _, source := range []string{cfg.NginxDir, cfg.BackupDir} {
+		paths, err := confPaths(source)
 		if err != nil {
-			// Directory might not exist, skip silently
-			return
+			errs = append(errs, err)
 		}
+		for _, path := range paths {
+			if cfg.ScanLimit > 0 && len(files) >= cfg.ScanLimit {
+				break
+			}
+			files = append(files, scanFile(path, source == cfg.NginxDir))
+		}
+	}
 
-		for _, entry := range entries {
-			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".conf") {
+	state, err := loadState()
+	if err != nil {
+		errs = append(errs, fmt.Errorf("reading state file: %w", err))
+	}
+	for i := range files {
+		files[i].Pinned = state.Pinned[files[i].Filename]
+		files[i].Tags = state.Tags[files[i].Filename]
+	}
+
+	return files, errs
+}
+
+// defaultServerConflicts describes every address:port that more than one
+// enabled config claims as default_server, which nginx refuses to load
+func defaultServerConflicts(files []FileData) []string {
+	claims := map[string][]string{}
+	var addresses []string
+
+	for _, file := range files {
+		if !file.enabled() {
+			continue
+		}
+		for _, listen := range file.listens {
+			if !listen.DefaultServer {
 				continue
 			}
+			if _, ok := claims[listen.Address]; !ok {
+				addresses = append(addresses, listen.Address)
+			}
+			claims[listen.Address] = append(claims[listen.Address], file.Filename)
+		}
+	}
+
+	var conflicts []string
+	for _, addr := range addresses {
+		if len(claims[addr]) > 1 {
+			conflicts = append(conflicts, fmt.Sprintf("multiple default_server configs for %s: %s",
+				addr, strings.Join(claims[addr], ", ")))
+		}
+	}
+	return conflicts
+}
+
+// duplicateServerNames maps each server_name claimed by more than one
+// enabled config to the files claiming it. Names compare case-insensitively,
+// as nginx lowercases them.
+func duplicateServerNames(files []FileData) map[string][]string {
+	claims := map[string][]string{}
 
-			filename := entry.Name()
-			fullPath := filepath.Join(dir, filename)
-
-			// Read file content to extract server_name
-			content, err := os.ReadFile(fullPath)
-			serverName := "unknown"
-			
-			if err == nil {
-				// Parse server_name from nginx config
-				re := regexp.MustCompile(`server_name\s+([^;]+);`)
-				matches := re.FindStringSubmatch(string(content))
-				if len(matches) > 1 {
-					serverName = strings.TrimSpace(matches[1])
-				} else {
-					// Try to find upstream or proxy configuration
-					// Check if it's a reverse proxy config
-					if strings.Contains(string(content), "proxy_pass") {
-						serverName = "reverse_proxy"
-					} else if strings.Contains(string(content), "location") {
-						serverName = "location_config"
-					} else {
-						serverName = "no_server_name"
-					}
-				}
+	for _, file := range files {
+		if !file.enabled() {
+			continue
+		}
+		seen := map[string]bool{}
+		for _, name := range file.ServerNames {
+			key := strings.ToLower(name.Name)
+			if seen[key] {
+				continue
 			}
+			seen[key] = true
+			claims[key] = append(claims[key], file.Filename)
+		}
+	}
 
-			files = append(files, FileData{
-				Filename:   filename,
-				ServerName: serverName,
-				CurrentDir: dir, // This tells us where the file is located
-			})
+	for name, owners := range claims {
+		if len(owners) < 2 {
+			delete(claims, name)
 		}
 	}
+	return claims
+}
 
-	// Scan both directories
-	processDir(cfg.NginxDir)  // Active sites
-	processDir(cfg.BackupDir)  // Disabled sites
+// 3. List Functionality - Show current state
+func handleList(args []string) {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	maxFileSize := fs.String("max-file-size", "", "skip parsing files larger than this (e.g. 10MB)")
+	glob := fs.String("glob", "", "scan the files matching this pattern (** allowed) as the enabled set")
+	output := fs.String("output", "json", "output format: json, table or template")
+	templateText := fs.String("template", "", "with --output=template, the text/template to render the whole list with")
+	templateFile := fs.String("template-file", "", "with --output=template, a file holding the template")
+	includeGz := fs.Bool("include-gz", false, "also parse gzipped .conf.gz files")
+	relativeTime := fs.Bool("relative-time", false, "show modification times as \"3 days ago\" in table output")
+	onlyEnabled := fs.Bool("enabled", false, "only list enabled configs")
+	onlyDisabled := fs.Bool("disabled", false, "only list disabled configs")
+	onlyServerNames := fs.Bool("only-server-names", false, "print each distinct server name (not regexes or catch-alls) one per line")
+	groupBy := fs.String("group-by", "", "group the JSON output by server_name")
+	limit := fs.Int("limit", 0, "stop after scanning this many files, enabled ones first, each directory in name order")
+	withHash := fs.Bool("with-hash", false, "include each file's SHA256 as content_hash")
+	var tags stringList
+	fs.Var(&tags, "tag", "only list configs with this tag, key=value or just key (repeatable)")
+	parseFlags(fs, args)
 
-	// Output JSON
-	jsonOutput, err := json.MarshalIndent(files, "", "  ")
-	if err != nil {
-		fmt.Println("Error generating JSON")
-		os.Exit(1)
+	if *groupBy != "" && *groupBy != "server_name" {
+		fail(ErrInvalid, "Error: unknown grouping %q, only server_name is supported", *groupBy)
+	}
+	if *groupBy != "" && *output != "json" {
+		fail(ErrInvalid, "Error: --group-by only applies to JSON output")
+	}
+
+	if *onlyEnabled && *onlyDisabled {
+		fail(ErrInvalid, "Error: --enabled and --disabled can't be combined")
+	}
+
+	if *output != "json" && *output != "table" && *output != "template" {
+		fail(ErrInvalid, "Error: unknown output format %q, use json, table or template", *output)
+	}
+
+	// Parse the template up front so a typo fails before the scan
+	var tmpl *template.Template
+	if *output == "template" {
+		var err error
+		if tmpl, err = listTemplate(*templateText, *templateFile); err != nil {
+			fail(ErrInvalid, "Error: %v", err)
+		}
+	}
+
+	if *glob != "" {
+		cfg.NginxDir = *glob
+	}
+	cfg.IncludeGz = *includeGz
+	if *limit < 0 {
+		fail(ErrInvalid, "Error: --limit can't be negative")
+	}
+	cfg.ScanLimit = *limit
+
+	if *maxFileSize != "" {
+		size, err := parseSize(*maxFileSize)
+		if err != nil {
+			fail(codeOf(err), "Error: %v", err)
+		}
+		cfg.MaxFileSize = size
+	}
+
+	files, errs := scanConfigs()
+
+	if *onlyEnabled || *onlyDisabled || len(tags) > 0 {
+		kept := []FileData{}
+		for _, file := range files {
+			if (*onlyEnabled || *onlyDisabled) && file.enabled() != *onlyEnabled {
+				continue
+			}
+			if !matchesTags(file.Tags, tags) {
+				continue
+			}
+			kept = append(kept, file)
+		}
+		files = kept
 	}
-	
-	fmt.Println(string(jsonOutput))
-}
\ No newline at end of file
+
+	if *withHash {
+		for i := range files {
+			// Skipped files stay unread, the hash included
+			if files[i].Skipped != "" || files[i].ParseError != "" {
+				continue
+			}
+			hash, err := hashFile(files[i].Path)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			files[i].ContentHash = hash
+		}
+	}
+
+	// Report where remote configs really are, not the fetched copy
+	for i := range files {
+		files[i].CurrentDir = remotePath(files[i].CurrentDir)
+		files[i].Path = remotePath(files[i].Path)
+	}
+
+	if *onlyServerNames {
+		for _, name := range distinctServerNames(files) {
+			fmt.Println(name)
+		}
+	} else if *output == "table" {
+		printFileTable(files, *relativeTime)
+	} else if *output == "template" {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, files); err != nil {
+			fail(ErrInvalid, "Error rendering template: %v", err)
+		}
+		fmt.Print(buf.String())
+	} else {
+		// Output JSON
+		var value interface{} = files
+		if *groupBy != "" {
+			value = groupByServerName(files)
+		}
+		jsonOutput, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			fail(ErrIO, "Error generating JSON")
+		}
+
+		fmt.Println(string(jsonOutput))
+	}
+
+	// Warnings go to stderr so the JSON on stdout stays parseable
+	failed := len(errs) > 0
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	for _, file := range files {
+		if file.ParseError != "" {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", file.ParseError)
+			failed = true
+		}
+	}
+	for _, conflict := range defaultServerConflicts(files) {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", conflict)
+	}
+	if failed {
+		exit(exitPartialFailure)
+	}
+}
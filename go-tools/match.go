@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+)
+
+// HostMatch is where nginx sends a request for a host on one address
+type HostMatch struct {
+	Address  string `json:"address"`
+	File     string `json:"file"`
+	Name     string `json:"server_name,omitempty"` // Empty when the default server answers
+	Type     string `json:"type"`                  // exact, wildcard, regex or default
+	Fallback bool   `json:"fallback"`              // No name matched, the default server answers
+}
+
+// matchHost picks the server nginx would choose among files, all of which
+// listen on the same address: exact name, longest leading wildcard, longest
+// trailing wildcard, first matching regex, and otherwise the default server.
+// Files are in the order nginx loads them, which breaks ties.
+func matchHost(addr string, files []FileData, host string) HostMatch {
+	best := HostMatch{Address: addr}
+	bestRank, bestLength := 4, 0
+	for _, file := range files {
+		for _, name := range file.ServerNames {
+			if !nameMatches(name, host) {
+				continue
+			}
+			rank, length := namePriority(name)
+			if rank < bestRank || (rank == bestRank && length < bestLength) {
+				best = HostMatch{Address: addr, File: file.Filename, Name: name.Name, Type: name.Type}
+				bestRank, bestLength = rank, length
+			}
+		}
+	}
+	if best.File != "" {
+		return best
+	}
+
+	// The default_server answers, or the first server for the address
+	best = HostMatch{Address: addr, File: files[0].Filename, Type: "default", Fallback: true}
+	for _, file := range files {
+		for _, listen := range file.listens {
+			if listen.Address == addr && listen.DefaultServer {
+				best.File = file.Filename
+				return best
+			}
+		}
+	}
+	return best
+}
+
+// 48. Match Functionality - Which config really serves a hostname
+func handleMatch(args []string) {
+	fs := flag.NewFlagSet("match", flag.ExitOnError)
+	listen := fs.String("listen", "", "only consider this address, e.g. *:443")
+	output := fs.String("output", "text", "output format: text or json")
+	positional := parseFlags(fs, args)
+
+	if len(positional) != 1 {
+		fail(ErrUsage, "Usage: ./conf-mover match [hostname] [--listen=*:443] [--output=json]")
+	}
+	if *output != "text" && *output != "json" {
+		fail(ErrInvalid, "Error: unknown output format %q, use text or json", *output)
+	}
+
+	// Hosts compare case-insensitively, without a port or trailing dot
+	host := strings.ToLower(positional[0])
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		host = name
+	}
+	host = strings.TrimSuffix(host, ".")
+
+	files, errs := scanConfigs()
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	byAddress := map[string][]FileData{}
+	for _, file := range files {
+		if !file.enabled() || file.ParseError != "" {
+			continue
+		}
+		for addr := range listenAddresses(file) {
+			if *listen == "" || addr == normalizeListenAddress(*listen) {
+				byAddress[addr] = append(byAddress[addr], file)
+			}
+		}
+	}
+
+	var addresses []string
+	for addr := range byAddress {
+		addresses = append(addresses, addr)
+	}
+	sort.Strings(addresses)
+
+	matches := []HostMatch{}
+	for _, addr := range addresses {
+		matches = append(matches, matchHost(addr, byAddress[addr], host))
+	}
+
+	if *output == "json" {
+		jsonOutput, _ := json.MarshalIndent(matches, "", "  ")
+		fmt.Println(string(jsonOutput))
+	} else {
+		for _, match := range matches {
+			if match.Fallback {
+				fmt.Printf("  %-16s %s (no server_name matches, default server)\n", match.Address, match.File)
+			} else {
+				fmt.Printf("  %-16s %s (%s %s)\n", match.Address, match.File, match.Type, match.Name)
+			}
+		}
+	}
+
+	if len(matches) == 0 && *listen != "" {
+		fail(ErrNotFound, "No enabled config listens on %s", *listen)
+	}
+	if len(matches) == 0 {
+		fail(ErrNotFound, "No enabled configs")
+	}
+}
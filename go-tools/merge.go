@@ -0,0 +1,154 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// mergeConflicts describes directives that would clash once the inputs
+// share one file: server_names declared twice, and top-level blocks or
+// directives such as upstream or map defined by more than one input
+func mergeConflicts(names []string, contents [][]byte) []string {
+	var conflicts []string
+
+	var files []FileData
+	definedBy := map[string]string{}
+	for i, content := range contents {
+		info := parseConf(string(content))
+		files = append(files, FileData{Filename: names[i], ServerNames: info.ServerNames, active: true})
+
+		for _, d := range parseDirectives(string(content)) {
+			if len(d.context) > 0 || d.name == "server" {
+				continue
+			}
+			key := d.name
+			if len(d.args) > 0 {
+				key += " " + d.args[0]
+			}
+			if other, ok := definedBy[key]; ok && other != names[i] {
+				conflicts = append(conflicts, fmt.Sprintf("%s is defined in both %s and %s", key, other, names[i]))
+			} else {
+				definedBy[key] = names[i]
+			}
+		}
+	}
+
+	var duplicated []string
+	claims := duplicateServerNames(files)
+	for name := range claims {
+		duplicated = append(duplicated, name)
+	}
+	sort.Strings(duplicated)
+	for _, name := range duplicated {
+		conflicts = append(conflicts, fmt.Sprintf("server_name %s is declared in %s", name, strings.Join(claims[name], ", ")))
+	}
+
+	return conflicts
+}
+
+// 32. Merge Functionality - Consolidate several configs into one
+func handleMerge(args []string) {
+	fs := flag.NewFlagSet("merge", flag.ExitOnError)
+	output := fs.String("output", "", "name of the merged config")
+	force := fs.Bool("force", false, "merge pinned configs too")
+	positional := parseFlags(fs, args)
+
+	if len(positional) < 2 || *output == "" {
+		fail(ErrUsage, "Usage: ./conf-mover merge [a.conf] [b.conf...] --output=combined.conf [--force]")
+	}
+
+	merged := confFilename(*output)
+	if err := validateConfName(merged); err != nil {
+		fail(codeOf(err), "Error: %v", err)
+	}
+	dst := filepath.Join(cfg.NginxDir, merged)
+	if _, err := os.Lstat(dst); err == nil {
+		fail(ErrDstExists, "Error: %s already exists", dst)
+	}
+
+	// Only enabled configs are merged, so the result replaces live sites
+	var names []string
+	var contents [][]byte
+	seen := map[string]bool{}
+	for _, arg := range positional {
+		name := confFilename(arg)
+		if seen[name] {
+			fail(ErrInvalid, "Error: %s is listed twice", name)
+		}
+		seen[name] = true
+
+		// The inputs are moved to backup, which pins protect against
+		if !*force {
+			pinned, err := isPinned(name)
+			if err != nil {
+				fail(codeOf(err), "Error: %v", err)
+			}
+			if pinned {
+				fail(ErrPinned, "Error: %s is pinned; unpin it or use --force", name)
+			}
+		}
+
+		content, err := os.ReadFile(filepath.Join(cfg.NginxDir, name))
+		if err != nil {
+			fail(codeOf(err), "Error: %s is not an enabled config: %v", name, err)
+		}
+		names = append(names, name)
+		contents = append(contents, content)
+	}
+
+	for _, conflict := range mergeConflicts(names, contents) {
+		fmt.Printf("⚠ %s\n", conflict)
+	}
+
+	var b strings.Builder
+	for i, content := range contents {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "# --- merged from %s ---\n", names[i])
+		b.Write(content)
+		if len(content) > 0 && content[len(content)-1] != '\n' {
+			b.WriteString("\n")
+		}
+	}
+
+	// Put things back the way they were if anything below fails
+	var backedUp []string
+	rollback := func() {
+		removeFile(dst)
+		for _, name := range backedUp {
+			if _, _, err := moveConf("restore", name); err != nil {
+				fail(codeOf(err), "❌ Rollback of %s failed: %v", name, err)
+			}
+		}
+		fmt.Println("↩ Restored the original configs")
+	}
+
+	if err := writeFileAtomic(dst, []byte(b.String())); err != nil {
+		fail(codeOf(err), "Error writing merged config: %v", err)
+	}
+	for _, name := range names {
+		if _, _, err := moveConf("backup", name); err != nil {
+			fmt.Println(errorLine(codeOf(err), fmt.Sprintf("❌ Backing up %s failed: %v", name, err)))
+			rollback()
+			exit(1)
+		}
+		backedUp = append(backedUp, name)
+	}
+
+	if output, err := testNginx(); err != nil {
+		fmt.Println(errorLine(ErrNginxTest, fmt.Sprintf("❌ Nginx config test failed:\n%s", output)))
+		rollback()
+		exit(1)
+	}
+	fmt.Println("✓ Nginx configuration test passed")
+
+	for _, name := range names {
+		fmt.Printf("Success: %s backed up to %s\n", name, cfg.BackupDir)
+	}
+	fmt.Printf("Success: merged %d configs into %s\n", len(names), dst)
+}
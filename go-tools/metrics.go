@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// metricLabel escapes a Prometheus label value
+func metricLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// printMetric writes one metric family header
+func printMetric(name, help string) {
+	fmt.Printf("# HELP %s %s\n", name, help)
+	fmt.Printf("# TYPE %s gauge\n", name)
+}
+
+// 9. Metrics Functionality - Prometheus text format for the textfile collector
+func handleMetrics() {
+	files, errs := scanConfigs()
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	enabled, disabled, http2, http3 := 0, 0, 0, 0
+	for _, file := range files {
+		if file.enabled() {
+			enabled++
+			if file.HTTP2Enabled {
+				http2++
+			}
+			if file.HTTP3Enabled {
+				http3++
+			}
+		} else {
+			disabled++
+		}
+	}
+
+	printMetric("confmover_enabled_total", "Number of enabled vhost configs.")
+	fmt.Printf("confmover_enabled_total %d\n", enabled)
+	printMetric("confmover_disabled_total", "Number of disabled vhost configs.")
+	fmt.Printf("confmover_disabled_total %d\n", disabled)
+	printMetric("confmover_duplicate_server_names_total", "Number of server_names claimed by more than one enabled config.")
+	fmt.Printf("confmover_duplicate_server_names_total %d\n", len(duplicateServerNames(files)))
+	printMetric("confmover_http2_enabled_total", "Number of enabled vhost configs serving HTTP/2.")
+	fmt.Printf("confmover_http2_enabled_total %d\n", http2)
+	printMetric("confmover_http3_enabled_total", "Number of enabled vhost configs serving HTTP/3 over QUIC.")
+	fmt.Printf("confmover_http3_enabled_total %d\n", http3)
+
+	printMetric("confmover_cert_expiry_seconds", "Seconds until the certificate expires, negative once expired.")
+	now := time.Now()
+	for _, file := range files {
+		if !file.enabled() {
+			continue
+		}
+		for _, cert := range file.certs {
+			expiry, err := certExpiry(cert)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %s: %v\n", file.Filename, err)
+				continue
+			}
+			fmt.Printf("confmover_cert_expiry_seconds{file=\"%s\",cert=\"%s\"} %d\n",
+				metricLabel(file.Filename), metricLabel(cert), int64(expiry.Sub(now).Seconds()))
+		}
+	}
+}
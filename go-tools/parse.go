@@ -0,0 +1,466 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// commentedServerNameRe finds a server_name that has been commented out
+var commentedServerNameRe = regexp.MustCompile(`#[^\n]*\bserver_name\b`)
+
+// Listen is a single listen directive
+type Listen struct {
+	Address       string // Normalized address:port, e.g. "*:80"
+	DefaultServer bool   // Carries the default_server (or legacy default) flag
+	SSL           bool   // Carries the ssl flag
+	SocketOptions bool   // Sets options nginx allows only once per address
+	HTTP2         bool   // Carries the http2 flag (the pre-1.25.1 form)
+	QUIC          bool   // Carries the quic flag, which serves HTTP/3
+}
+
+// listenSocketOptions are the listen parameters that configure the socket
+// itself, so nginx rejects them on more than one listen for an address
+var listenSocketOptions = []string{
+	"backlog=", "rcvbuf=", "sndbuf=", "accept_filter=", "deferred", "bind",
+	"ipv6only=", "reuseport", "fastopen=", "so_keepalive=", "setfib=",
+}
+
+// ServerName is a single name from a server_name directive
+type ServerName struct {
+	Name string `json:"name"`
+	Type string `json:"type"` // exact, wildcard, regex or default
+}
+
+// ConfInfo holds everything extracted from one config file
+type ConfInfo struct {
+	ServerName  string       // Arguments of the first server_name directive
+	ServerNames []ServerName // Every name from every server_name directive
+	LogFiles    []string     // Local files named by access_log/error_log
+	Listens     []Listen     // Every listen directive
+	Certs       []string     // Files named by ssl_certificate
+	ProxyPasses []string     // Distinct proxy_pass targets, in file order
+	Includes    []string     // Paths from include directives, as written
+	Warnings    []string     // Soft problems worth a look, e.g. a missing ';'
+	TLS         []TLSSetting // Every ssl_protocols and ssl_ciphers directive
+	Upstreams   []Upstream   // Every upstream block
+	HTTP2       bool         // "http2 on;" or a listen ... http2
+	HTTP3       bool         // A listen ... quic, unless "http3 off;"
+}
+
+// Upstream is one upstream block and the servers it balances across
+type Upstream struct {
+	Name    string
+	Servers []string // Addresses as written, e.g. "10.0.0.1:8080"
+	Line    int
+}
+
+// TLSSetting is one ssl_protocols or ssl_ciphers directive
+type TLSSetting struct {
+	Directive string
+	Args      []string
+	Line      int
+}
+
+// token is one word of a directive
+type token struct {
+	text   string
+	line   int
+	quoted bool
+	offset int // Byte offset of the word (its opening quote, if quoted)
+}
+
+// directive is one nginx directive together with where it appears
+type directive struct {
+	name    string
+	args    []string
+	tokens  []token  // The arguments with their positions
+	line    int      // Line the directive starts on
+	context []string // Enclosing block names, outermost first
+	block   bool     // Opens a block rather than ending with ";"
+	start   int      // Byte offset where the directive begins
+	end     int      // Byte offset just past its ";" or closing "}"
+
+	// A missing ";" is repaired by ending the directive where a known
+	// directive name starts a later line. unterminated marks the directive
+	// that lacked the ";", split marks the one that followed it.
+	unterminated bool
+	split        bool
+}
+
+// knownDirectives are names that start a new directive when they open a
+// line, even if the previous directive wasn't terminated
+var knownDirectives = map[string]bool{
+	"server": true, "server_name": true, "listen": true, "location": true,
+	"root": true, "index": true, "return": true, "rewrite": true,
+	"try_files": true, "include": true, "proxy_pass": true,
+	"proxy_set_header": true, "proxy_redirect": true, "proxy_http_version": true,
+	"fastcgi_pass": true, "fastcgi_param": true, "uwsgi_pass": true,
+	"ssl_certificate": true, "ssl_certificate_key": true, "ssl_protocols": true,
+	"ssl_ciphers": true, "add_header": true, "access_log": true,
+	"error_log": true, "error_page": true, "client_max_body_size": true,
+	"gzip": true, "charset": true, "set": true, "if": true, "upstream": true,
+	"allow": true, "deny": true, "auth_basic": true, "alias": true,
+	"expires": true, "http2": true,
+}
+
+// argumentShapes describe the arguments of known directives that are also
+// plausible host names. A known name whose following words don't fit is
+// taken to continue the directive before it, e.g. a host called "allow" on
+// its own line of a multi-line server_name.
+var argumentShapes = map[string]func(args []token) bool{
+	"set": func(args []token) bool {
+		return len(args) >= 2 && strings.HasPrefix(args[0].text, "$")
+	},
+	"if": func(args []token) bool {
+		return len(args) > 0 && strings.HasPrefix(args[0].text, "(")
+	},
+	"allow": accessArgs,
+	"deny":  accessArgs,
+}
+
+// accessArgs reports whether args are what allow and deny take: an address,
+// a CIDR range, a unix: socket or "all"
+func accessArgs(args []token) bool {
+	if len(args) == 0 {
+		return false
+	}
+	addr := args[0].text
+	_, _, cidrErr := net.ParseCIDR(addr)
+	return addr == "all" || strings.HasPrefix(addr, "unix:") || net.ParseIP(addr) != nil || cidrErr == nil
+}
+
+// startsDirective reports whether words, which begin with a known directive
+// name on a new line, can be a directive of their own. block is set when
+// they end at a "{".
+func startsDirective(words []token, block bool) bool {
+	args := words[1:]
+	if len(args) == 0 && !block {
+		// Every known directive ending in ";" takes arguments
+		return false
+	}
+	if shape := argumentShapes[words[0].text]; shape != nil {
+		return shape(args)
+	}
+	return true
+}
+
+// in reports whether the innermost enclosing block is named block
+func (d directive) in(block string) bool {
+	return len(d.context) > 0 && d.context[len(d.context)-1] == block
+}
+
+// within reports whether any enclosing block is named block
+func (d directive) within(block string) bool {
+	for _, name := range d.context {
+		if name == block {
+			return true
+		}
+	}
+	return false
+}
+
+// parseDirectives tokenizes an nginx config and tracks brace depth, so
+// each directive knows which blocks it sits in. Comments are dropped and
+// quoted strings are unquoted. Directives left open at a "}" or at the end
+// of the content are still returned.
+func parseDirectives(content string) []directive {
+	var directives []directive
+	var context []string
+	var open []int // Index in directives of each block in context
+	var words []token
+	line := 1
+
+	emit := func(block bool, offset int) {
+		split := false
+		for len(words) > 0 {
+			// Look for a known directive opening a later line
+			end := len(words)
+			for k := 1; k < len(words); k++ {
+				if !words[k].quoted && words[k].line > words[k-1].line && knownDirectives[words[k].text] &&
+					startsDirective(words[k:], block) {
+					end = k
+					break
+				}
+			}
+
+			d := directive{
+				name:         words[0].text,
+				tokens:       words[1:end],
+				line:         words[0].line,
+				context:      append([]string(nil), context...),
+				block:        block && end == len(words),
+				start:        words[0].offset,
+				end:          offset,
+				unterminated: end < len(words),
+				split:        split,
+			}
+			if d.unterminated {
+				d.end = words[end].offset
+			}
+			for _, word := range d.tokens {
+				d.args = append(d.args, word.text)
+			}
+			directives = append(directives, d)
+			if d.block {
+				context = append(context, d.name)
+				open = append(open, len(directives)-1)
+			}
+
+			words = words[end:]
+			split = true
+		}
+	}
+
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case c == '\n':
+			line++
+		case c == ' ' || c == '\t' || c == '\r':
+		case c == '#':
+			// Comment runs to the end of the line
+			for i+1 < len(content) && content[i+1] != '\n' {
+				i++
+			}
+		case c == ';':
+			emit(false, i+1)
+		case c == '{':
+			emit(true, i+1)
+		case c == '}':
+			emit(false, i)
+			if len(context) > 0 {
+				context = context[:len(context)-1]
+				directives[open[len(open)-1]].end = i + 1
+				open = open[:len(open)-1]
+			}
+		case c == '"' || c == '\'':
+			wordLine, wordStart := line, i
+			var word strings.Builder
+			for i++; i < len(content) && content[i] != c; i++ {
+				if content[i] == '\\' && i+1 < len(content) && content[i+1] == c {
+					i++
+				} else if content[i] == '\n' {
+					line++
+				}
+				word.WriteByte(content[i])
+			}
+			words = append(words, token{word.String(), wordLine, true, wordStart})
+		default:
+			j := i
+			for j < len(content) && !strings.ContainsRune(" \t\r\n;{}\"'", rune(content[j])) {
+				// ${var} is part of the word, not a block
+				if content[j] == '$' && j+1 < len(content) && content[j+1] == '{' {
+					if end := strings.IndexByte(content[j:], '}'); end > 0 {
+						j += end
+					}
+				}
+				j++
+			}
+			words = append(words, token{content[i:j], line, false, i})
+			i = j - 1
+		}
+	}
+	emit(false, len(content))
+
+	// Blocks still open at the end run to the end of the content
+	for _, index := range open {
+		directives[index].end = len(content)
+	}
+
+	return directives
+}
+
+// parseConf extracts the interesting directives from a config's content
+func parseConf(content string) ConfInfo {
+	var info ConfInfo
+	seenLog := map[string]bool{}
+	seenProxy := map[string]bool{}
+	foundServerName := false
+
+	http3Off := false
+
+	// Which server block (counting from 1) first declared each name
+	servers := 0
+	declaredIn := map[string]int{}
+
+	for _, d := range parseDirectives(content) {
+		if d.unterminated {
+			info.Warnings = append(info.Warnings, fmt.Sprintf("line %d: %s is missing a ';'", d.line, d.name))
+		}
+
+		switch d.name {
+		case "server":
+			if d.block {
+				servers++
+			} else if d.in("upstream") && len(d.args) > 0 && len(info.Upstreams) > 0 {
+				last := &info.Upstreams[len(info.Upstreams)-1]
+				last.Servers = append(last.Servers, d.args[0])
+			}
+		case "http2":
+			if len(d.args) > 0 && d.args[0] == "on" {
+				info.HTTP2 = true
+			}
+		case "http3":
+			if len(d.args) > 0 && d.args[0] == "off" {
+				http3Off = true
+			}
+		case "upstream":
+			if d.block && len(d.args) > 0 {
+				info.Upstreams = append(info.Upstreams, Upstream{Name: d.args[0], Line: d.line})
+			}
+		case "server_name":
+			// Only server blocks define names; the same word inside a map,
+			// geo or other block is just data
+			if !d.in("server") {
+				continue
+			}
+			if !foundServerName {
+				info.ServerName = strings.Join(d.args, " ")
+				foundServerName = true
+			}
+			for _, name := range d.args {
+				info.ServerNames = append(info.ServerNames, classifyServerName(name))
+
+				if first, ok := declaredIn[name]; !ok {
+					declaredIn[name] = servers
+				} else if first != servers {
+					info.Warnings = append(info.Warnings, fmt.Sprintf("line %d: %q is also the name of server block %d", d.line, name, first))
+				}
+			}
+		case "access_log", "error_log":
+			// Skip "off", syslog targets and anything else that isn't a file
+			if len(d.args) == 0 || !strings.HasPrefix(d.args[0], "/") || seenLog[d.args[0]] {
+				continue
+			}
+			seenLog[d.args[0]] = true
+			info.LogFiles = append(info.LogFiles, d.args[0])
+		case "listen":
+			if d.in("server") && len(d.args) > 0 {
+				info.Listens = append(info.Listens, parseListen(d.args))
+			}
+		case "ssl_certificate":
+			if len(d.args) > 0 {
+				info.Certs = append(info.Certs, d.args[0])
+			}
+		case "ssl_protocols", "ssl_ciphers":
+			info.TLS = append(info.TLS, TLSSetting{d.name, d.args, d.line})
+		case "include":
+			if len(d.args) > 0 {
+				info.Includes = append(info.Includes, d.args[0])
+			}
+		case "proxy_pass":
+			if len(d.args) > 0 && !seenProxy[d.args[0]] {
+				seenProxy[d.args[0]] = true
+				info.ProxyPasses = append(info.ProxyPasses, d.args[0])
+			}
+		}
+	}
+
+	for _, listen := range info.Listens {
+		info.HTTP2 = info.HTTP2 || listen.HTTP2
+		info.HTTP3 = info.HTTP3 || (listen.QUIC && !http3Off)
+	}
+
+	if !foundServerName && commentedServerNameRe.MatchString(content) {
+		info.Warnings = append(info.Warnings, "server_name only appears in a comment")
+	}
+
+	if !foundServerName {
+		if strings.Contains(content, "proxy_pass") {
+			// Try to find upstream or proxy configuration
+			// Check if it's a reverse proxy config
+			info.ServerName = "reverse_proxy"
+		} else if strings.Contains(content, "location") {
+			info.ServerName = "location_config"
+		} else {
+			info.ServerName = "no_server_name"
+		}
+	}
+
+	return info
+}
+
+// IsDefault reports whether any listen directive claims default_server
+func (info ConfInfo) IsDefault() bool {
+	for _, listen := range info.Listens {
+		if listen.DefaultServer {
+			return true
+		}
+	}
+	return false
+}
+
+// parseListen interprets the arguments of a listen directive
+func parseListen(args []string) Listen {
+	listen := Listen{Address: normalizeListenAddress(args[0])}
+	for _, param := range args[1:] {
+		if param == "default_server" || param == "default" {
+			listen.DefaultServer = true
+		}
+		if param == "ssl" {
+			listen.SSL = true
+		}
+		if param == "http2" {
+			listen.HTTP2 = true
+		}
+		if param == "quic" {
+			listen.QUIC = true
+		}
+		for _, option := range listenSocketOptions {
+			if param == option || (strings.HasSuffix(option, "=") && strings.HasPrefix(param, option)) {
+				listen.SocketOptions = true
+			}
+		}
+	}
+	return listen
+}
+
+// normalizeListenAddress expands the shorthand forms nginx accepts so that
+// equivalent listen addresses compare equal: "80" becomes "*:80" and
+// "127.0.0.1" becomes "127.0.0.1:80"
+func normalizeListenAddress(addr string) string {
+	if strings.HasPrefix(addr, "unix:") {
+		return addr
+	}
+	if strings.HasPrefix(addr, "0.0.0.0:") {
+		// Same as listening on all addresses
+		addr = "*" + addr[len("0.0.0.0"):]
+	}
+	if _, err := strconv.Atoi(addr); err == nil {
+		// Only a port
+		return "*:" + addr
+	}
+	if strings.HasPrefix(addr, "[") {
+		// IPv6, the port is after the closing bracket
+		if strings.HasSuffix(addr, "]") {
+			return addr + ":80"
+		}
+		return addr
+	}
+	if !strings.Contains(addr, ":") {
+		// Only a host
+		return addr + ":80"
+	}
+	return addr
+}
+
+// classifyServerName sorts a name into the forms nginx's server_name
+// grammar distinguishes between
+func classifyServerName(name string) ServerName {
+	switch {
+	case strings.HasPrefix(name, "~"):
+		// ~ introduces a regular expression
+		return ServerName{Name: name, Type: "regex"}
+	case name == "_" || name == "":
+		// "_" is the conventional catch-all, "" matches requests without Host
+		return ServerName{Name: name, Type: "default"}
+	case strings.HasPrefix(name, "*.") || strings.HasSuffix(name, ".*") || strings.HasPrefix(name, "."):
+		// ".example.com" is shorthand for example.com plus *.example.com
+		return ServerName{Name: name, Type: "wildcard"}
+	default:
+		return ServerName{Name: name, Type: "exact"}
+	}
+}
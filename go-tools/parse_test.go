@@ -0,0 +1,275 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseConfServerNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []ServerName
+	}{
+		{
+			name:    "exact",
+			content: "server {\n    server_name example.com;\n}\n",
+			want:    []ServerName{{"example.com", "exact"}},
+		},
+		{
+			name:    "leading wildcard",
+			content: "server { server_name *.example.com; }",
+			want:    []ServerName{{"*.example.com", "wildcard"}},
+		},
+		{
+			name:    "trailing wildcard",
+			content: "server { server_name www.example.*; }",
+			want:    []ServerName{{"www.example.*", "wildcard"}},
+		},
+		{
+			name:    "dot shorthand",
+			content: "server { server_name .example.com; }",
+			want:    []ServerName{{".example.com", "wildcard"}},
+		},
+		{
+			name:    "regex",
+			content: `server { server_name ~^(?<sub>.+)\.example\.com$; }`,
+			want:    []ServerName{{`~^(?<sub>.+)\.example\.com$`, "regex"}},
+		},
+		{
+			name:    "catch-all",
+			content: "server { listen 80 default_server; server_name _; }",
+			want:    []ServerName{{"_", "default"}},
+		},
+		{
+			name:    "empty name",
+			content: `server { server_name ""; }`,
+			want:    []ServerName{{"", "default"}},
+		},
+		{
+			name:    "multiple names",
+			content: "server { server_name example.com www.example.com *.example.org ~^api\\.; }",
+			want: []ServerName{
+				{"example.com", "exact"},
+				{"www.example.com", "exact"},
+				{"*.example.org", "wildcard"},
+				{`~^api\.`, "regex"},
+			},
+		},
+		{
+			name:    "quoted names",
+			content: `server { server_name "example.com" '*.example.com' "~^(www\.)?example\.net$"; }`,
+			want: []ServerName{
+				{"example.com", "exact"},
+				{"*.example.com", "wildcard"},
+				{`~^(www\.)?example\.net$`, "regex"},
+			},
+		},
+		{
+			name:    "several server blocks",
+			content: "server { server_name a.example.com; }\nserver { server_name b.example.com; }\n",
+			want:    []ServerName{{"a.example.com", "exact"}, {"b.example.com", "exact"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := parseConf(tt.content)
+			if !reflect.DeepEqual(info.ServerNames, tt.want) {
+				t.Errorf("ServerNames = %v, want %v", info.ServerNames, tt.want)
+			}
+			if len(info.Warnings) > 0 {
+				t.Errorf("unexpected warnings: %v", info.Warnings)
+			}
+		})
+	}
+}
+
+func TestParseConfIgnoresNamesOutsideServerBlocks(t *testing.T) {
+	content := `map $http_host $backend {
+    hostnames;
+    server_name      legacy;
+    example.com      app;
+    *.example.com    app;
+    default          web;
+}
+
+geo $remote_addr $server_name {
+    default          example.com;
+    10.0.0.0/8       internal.example.com;
+}
+
+server {
+    listen 80;
+    server_name example.com;
+}
+`
+	info := parseConf(content)
+
+	want := []ServerName{{"example.com", "exact"}}
+	if !reflect.DeepEqual(info.ServerNames, want) {
+		t.Errorf("ServerNames = %v, want %v", info.ServerNames, want)
+	}
+	if info.ServerName != "example.com" {
+		t.Errorf("ServerName = %q, want %q", info.ServerName, "example.com")
+	}
+	if len(info.Warnings) > 0 {
+		t.Errorf("unexpected warnings: %v", info.Warnings)
+	}
+}
+
+func TestParseConfMultiLineDirectives(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		want     []string // Server names
+		warnings []string
+		listens  int
+	}{
+		{
+			name: "names across lines",
+			content: `server {
+    server_name example.com
+                www.example.com
+                *.example.org;
+    listen 80;
+}
+`,
+			want:    []string{"example.com", "www.example.com", "*.example.org"},
+			listens: 1,
+		},
+		{
+			name: "missing semicolon before listen",
+			content: `server {
+    server_name example.com
+    listen 80;
+    root /var/www;
+}
+`,
+			want:     []string{"example.com"},
+			warnings: []string{"line 2: server_name is missing a ';'"},
+			listens:  1,
+		},
+		{
+			name: "missing semicolon before a block",
+			content: `server {
+    server_name example.com www.example.com
+    location / {
+        listen 8080;
+    }
+}
+`,
+			want:     []string{"example.com", "www.example.com"},
+			warnings: []string{"line 2: server_name is missing a ';'"},
+		},
+		{
+			name: "missing semicolon before allow",
+			content: `server {
+    server_name example.com
+    allow 10.0.0.0/8;
+    deny all;
+}
+`,
+			want:     []string{"example.com"},
+			warnings: []string{"line 2: server_name is missing a ';'"},
+		},
+		{
+			name: "missing semicolon before set",
+			content: `server {
+    server_name example.com
+    set $backend app;
+}
+`,
+			want:     []string{"example.com"},
+			warnings: []string{"line 2: server_name is missing a ';'"},
+		},
+		{
+			name: "missing semicolon before if",
+			content: `server {
+    server_name example.com
+    if ($host = old.example.com) {
+        return 301 https://example.com$request_uri;
+    }
+}
+`,
+			want:     []string{"example.com"},
+			warnings: []string{"line 2: server_name is missing a ';'"},
+		},
+		{
+			name: "continuation lines named like directives",
+			content: `server {
+    server_name example.com
+                allow
+                set
+                if;
+    listen 80;
+}
+`,
+			want:    []string{"example.com", "allow", "set", "if"},
+			listens: 1,
+		},
+		{
+			name: "continuation named like a directive before the next directive",
+			content: `server {
+    server_name example.com
+                deny
+    listen 80;
+}
+`,
+			want:     []string{"example.com", "deny"},
+			warnings: []string{"line 2: server_name is missing a ';'"},
+			listens:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := parseConf(tt.content)
+
+			var names []string
+			for _, name := range info.ServerNames {
+				names = append(names, name.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("server names = %q, want %q", names, tt.want)
+			}
+			if !reflect.DeepEqual(info.Warnings, tt.warnings) {
+				t.Errorf("warnings = %q, want %q", info.Warnings, tt.warnings)
+			}
+			if len(info.Listens) != tt.listens {
+				t.Errorf("got %d listen(s), want %d", len(info.Listens), tt.listens)
+			}
+		})
+	}
+}
+
+func TestParseConfCRLF(t *testing.T) {
+	content := "server {\r\n" +
+		"    listen 80;\r\n" +
+		"    server_name  crlf.example.com\t www.crlf.example.com\r\n" +
+		"                 *.crlf.example.org\r\n" +
+		"                 ;\r\n" +
+		"    server_name api.crlf.example.com\r\n" +
+		"    root /var/www;\r\n" +
+		"}\r\n"
+	info := parseConf(content)
+
+	if want := "crlf.example.com www.crlf.example.com *.crlf.example.org"; info.ServerName != want {
+		t.Errorf("ServerName = %q, want %q", info.ServerName, want)
+	}
+	want := []ServerName{
+		{"crlf.example.com", "exact"},
+		{"www.crlf.example.com", "exact"},
+		{"*.crlf.example.org", "wildcard"},
+		{"api.crlf.example.com", "exact"},
+	}
+	if !reflect.DeepEqual(info.ServerNames, want) {
+		t.Errorf("ServerNames = %q, want %q", info.ServerNames, want)
+	}
+	if warnings := []string{"line 6: server_name is missing a ';'"}; !reflect.DeepEqual(info.Warnings, warnings) {
+		t.Errorf("warnings = %q, want %q", info.Warnings, warnings)
+	}
+	if len(info.Listens) != 1 || info.Listens[0].Address != "*:80" {
+		t.Errorf("Listens = %v, want one on *:80", info.Listens)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Changes lists the enabled files that differ from the last reload snapshot
+type Changes struct {
+	Added    []string
+	Removed  []string
+	Modified []string
+}
+
+// Empty reports whether there is nothing for a reload to activate
+func (c Changes) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Modified) == 0
+}
+
+// hashFile returns the hex SHA-256 of a file's content
+func hashFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// enabledHashes hashes every enabled config. Files are keyed by name, or by
+// full path when NGINX_DIR is a glob that may span several directories.
+func enabledHashes() (map[string]string, error) {
+	hashes := map[string]string{}
+
+	paths, err := confPaths(cfg.NginxDir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range paths {
+		hash, err := hashFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		key := filepath.Base(path)
+		if isGlobPattern(cfg.NginxDir) {
+			key = path
+		}
+		hashes[key] = hash
+	}
+
+	return hashes, nil
+}
+
+// recordReloadSnapshot stores the current enabled hashes as "last reloaded"
+func recordReloadSnapshot() error {
+	hashes, err := enabledHashes()
+	if err != nil {
+		return err
+	}
+
+	state, err := loadState()
+	if err != nil {
+		return err
+	}
+	state.LastReload = hashes
+	return saveState(state)
+}
+
+// pendingChanges compares the enabled files against the last reload snapshot.
+// When no reload has been recorded yet, every enabled file counts as added.
+func pendingChanges() (Changes, error) {
+	var changes Changes
+
+	current, err := enabledHashes()
+	if err != nil {
+		return changes, err
+	}
+
+	state, err := loadState()
+	if err != nil {
+		return changes, err
+	}
+
+	for name, hash := range current {
+		previous, ok := state.LastReload[name]
+		if !ok {
+			changes.Added = append(changes.Added, name)
+		} else if previous != hash {
+			changes.Modified = append(changes.Modified, name)
+		}
+	}
+	for name := range state.LastReload {
+		if _, ok := current[name]; !ok {
+			changes.Removed = append(changes.Removed, name)
+		}
+	}
+
+	sort.Strings(changes.Added)
+	sort.Strings(changes.Removed)
+	sort.Strings(changes.Modified)
+	return changes, nil
+}
+
+// 4. Pending Functionality - Show what a reload would activate
+func handlePending() {
+	changes, err := pendingChanges()
+	if err != nil {
+		fail(codeOf(err), "Error checking pending changes: %v", err)
+	}
+
+	if changes.Empty() {
+		fmt.Println("✓ No changes since last reload")
+		return
+	}
+
+	fmt.Println("Changes since last reload:")
+	for _, name := range changes.Added {
+		fmt.Printf("  + %s (added)\n", name)
+	}
+	for _, name := range changes.Removed {
+		fmt.Printf("  - %s (removed)\n", name)
+	}
+	for _, name := range changes.Modified {
+		fmt.Printf("  ~ %s (modified)\n", name)
+	}
+}
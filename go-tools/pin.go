@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+)
+
+// isPinned reports whether filename is pinned. An unreadable state file
+// counts as pinned, since the point is to fail safe.
+func isPinned(filename string) (bool, error) {
+	state, err := loadState()
+	if err != nil {
+		return true, fmt.Errorf("reading state file: %w", err)
+	}
+	return state.Pinned[confFilename(filename)], nil
+}
+
+// mayDisable is the pin check for everything that disables configs. With
+// force any config goes. Otherwise a pinned one named directly is refused,
+// and one picked out by a bulk operation (--match, an operations file) is
+// skipped with a warning.
+func mayDisable(filename string, force, bulk bool) (bool, error) {
+	if force {
+		return true, nil
+	}
+	pinned, err := isPinned(filename)
+	if err != nil || !pinned {
+		return !pinned, err
+	}
+	if !bulk {
+		return false, withCode(ErrPinned, fmt.Errorf("%s is pinned; unpin it or use --force", confFilename(filename)))
+	}
+	fmt.Printf("⚠ Skipping pinned %s (use --force to include it)\n", confFilename(filename))
+	return false, nil
+}
+
+// 29. Pin Functionality - Protect essential configs from being disabled
+func handlePin(args []string, pin bool) {
+	command := "pin"
+	if !pin {
+		command = "unpin"
+	}
+	if len(args) == 0 {
+		fail(ErrUsage, "Usage: ./conf-mover %s [filename...]", command)
+	}
+
+	state, err := loadState()
+	if err != nil {
+		fail(codeOf(err), "Error reading state file: %v", err)
+	}
+	if state.Pinned == nil {
+		state.Pinned = map[string]bool{}
+	}
+
+	for _, name := range args {
+		filename := confFilename(name)
+		if pin {
+			if _, err := findConf(filename); err != nil {
+				fail(codeOf(err), "Error: %v", err)
+			}
+			state.Pinned[filename] = true
+		} else {
+			delete(state.Pinned, filename)
+		}
+	}
+
+	if err := saveState(state); err != nil {
+		fail(codeOf(err), "Error writing state file: %v", err)
+	}
+	for _, name := range args {
+		fmt.Printf("Success: %sned %s\n", command, confFilename(name))
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// portBinding is one enabled config listening on an address:port
+type portBinding struct {
+	file   FileData
+	listen Listen
+}
+
+// bindingsByAddress groups the listen directives of enabled configs
+func bindingsByAddress(files []FileData) map[string][]portBinding {
+	bindings := map[string][]portBinding{}
+	for _, file := range files {
+		if !file.enabled() {
+			continue
+		}
+		for _, listen := range file.listens {
+			bindings[listen.Address] = append(bindings[listen.Address], portBinding{file, listen})
+		}
+	}
+	return bindings
+}
+
+// portConflicts describes every address:port whose configs disagree in a
+// way nginx rejects or silently resolves
+func portConflicts(files []FileData) []string {
+	conflicts := defaultServerConflicts(files)
+
+	bindings := bindingsByAddress(files)
+	var addresses []string
+	for addr := range bindings {
+		addresses = append(addresses, addr)
+	}
+	sort.Strings(addresses)
+
+	for _, addr := range addresses {
+		var ssl, plain, options []string
+		names := map[string][]string{}
+
+		for _, b := range bindings[addr] {
+			if b.listen.SSL {
+				ssl = append(ssl, b.file.Filename)
+			} else {
+				plain = append(plain, b.file.Filename)
+			}
+			if b.listen.SocketOptions {
+				options = append(options, b.file.Filename)
+			}
+			for _, name := range b.file.ServerNames {
+				key := strings.ToLower(name.Name)
+				if owners := names[key]; len(owners) == 0 || owners[len(owners)-1] != b.file.Filename {
+					names[key] = append(owners, b.file.Filename)
+				}
+			}
+		}
+
+		if len(ssl) > 0 && len(plain) > 0 {
+			conflicts = append(conflicts, fmt.Sprintf("%s mixes ssl (%s) and plain (%s) listens",
+				addr, strings.Join(ssl, ", "), strings.Join(plain, ", ")))
+		}
+		if len(options) > 1 {
+			conflicts = append(conflicts, fmt.Sprintf("%s has socket options set by more than one config: %s",
+				addr, strings.Join(options, ", ")))
+		}
+
+		var duplicated []string
+		for name, owners := range names {
+			if len(owners) > 1 {
+				duplicated = append(duplicated, fmt.Sprintf("%s (%s)", name, strings.Join(owners, ", ")))
+			}
+		}
+		sort.Strings(duplicated)
+		for _, dup := range duplicated {
+			conflicts = append(conflicts, fmt.Sprintf("%s serves the same server_name from several configs: %s", addr, dup))
+		}
+	}
+
+	return conflicts
+}
+
+// 16. Ports Functionality - Who listens where, and what clashes
+func handlePorts() {
+	files, errs := scanConfigs()
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	bindings := bindingsByAddress(files)
+	var addresses []string
+	for addr := range bindings {
+		addresses = append(addresses, addr)
+	}
+	sort.Strings(addresses)
+
+	for _, addr := range addresses {
+		fmt.Println(addr)
+		for _, b := range bindings[addr] {
+			var flags []string
+			if b.listen.DefaultServer {
+				flags = append(flags, "default_server")
+			}
+			if b.listen.SSL {
+				flags = append(flags, "ssl")
+			}
+			if len(flags) > 0 {
+				fmt.Printf("  %s (%s)\n", b.file.Filename, strings.Join(flags, ", "))
+			} else {
+				fmt.Printf("  %s\n", b.file.Filename)
+			}
+		}
+	}
+
+	conflicts := portConflicts(files)
+	if len(conflicts) == 0 {
+		fmt.Println("✓ No port conflicts")
+		return
+	}
+
+	for _, conflict := range conflicts {
+		fmt.Printf("❌ %s\n", conflict)
+	}
+	exit(1)
+}
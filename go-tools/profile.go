@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+)
+
+// takeProfileFlags removes --cpuprofile and --memprofile from os.Args,
+// wherever they appear, so commands never see them. Both accept "=file" or
+// the file as the next argument.
+func takeProfileFlags() (cpuFile, memFile string) {
+	args := os.Args[:1]
+	for i := 1; i < len(os.Args); i++ {
+		arg := os.Args[i]
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || (name != "cpuprofile" && name != "memprofile") {
+			args = append(args, arg)
+			continue
+		}
+		if !hasValue && i+1 < len(os.Args) {
+			i++
+			value = os.Args[i]
+		}
+		if name == "cpuprofile" {
+			cpuFile = value
+		} else {
+			memFile = value
+		}
+	}
+	os.Args = args
+	return cpuFile, memFile
+}
+
+// startProfiling starts whatever profiling was asked for on the command
+// line and returns a function that writes the profiles out. With neither
+// flag given nothing is started.
+func startProfiling() func() {
+	cpuFile, memFile := takeProfileFlags()
+	var cpu *os.File
+
+	if cpuFile != "" {
+		f, err := os.Create(cpuFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not create CPU profile: %v\n", err)
+		} else if err := pprof.StartCPUProfile(f); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not start CPU profile: %v\n", err)
+			f.Close()
+		} else {
+			cpu = f
+		}
+	}
+
+	return func() {
+		if cpu != nil {
+			pprof.StopCPUProfile()
+			cpu.Close()
+		}
+		if memFile == "" {
+			return
+		}
+
+		f, err := os.Create(memFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not create memory profile: %v\n", err)
+			return
+		}
+		defer f.Close()
+		runtime.GC() // Up-to-date allocation statistics
+		if err := pprof.WriteHeapProfile(f); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not write memory profile: %v\n", err)
+		}
+	}
+}
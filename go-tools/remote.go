@@ -0,0 +1,181 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// sshLocation is an NGINX_DIR or BACKUP_DIR given as ssh://user@host/path
+type sshLocation struct {
+	dest string // What ssh connects to, e.g. user@host
+	port string // "" for ssh's default
+	path string // Directory on the remote host
+}
+
+// remoteDir is an ssh:// directory fetched for this run
+type remoteDir struct {
+	url   string // As configured
+	local string // Where its configs were fetched to
+}
+
+// remoteDirs are the ssh:// directories this run fetched
+var remoteDirs []remoteDir
+
+// readsRemote lists the commands that can work from fetched copies of ssh://
+// directories: nothing they do has to be written back
+var readsRemote = map[string]bool{"list": true}
+
+// parseRemoteDir recognizes an ssh:// location. ok is false for local paths.
+func parseRemoteDir(dir string) (loc sshLocation, ok bool, err error) {
+	if !strings.HasPrefix(dir, "ssh://") {
+		return loc, false, nil
+	}
+	u, err := url.Parse(dir)
+	if err != nil {
+		return loc, true, err
+	}
+	if u.Hostname() == "" || u.Path == "" {
+		return loc, true, fmt.Errorf("invalid remote directory %q, use ssh://user@host/path", dir)
+	}
+
+	loc = sshLocation{dest: u.Hostname(), port: u.Port(), path: u.Path}
+	if u.User != nil {
+		loc.dest = u.User.Username() + "@" + loc.dest
+	}
+	return loc, true, nil
+}
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fetchRemoteDir copies the configs (and the state file, for BACKUP_DIR)
+// in loc's directory into dest, keeping their modification times. It runs
+// find and tar on the remote host over ssh, so it needs nothing but an ssh
+// client here. Symlinks are followed, and like a local directory one that
+// doesn't exist yields nothing.
+func fetchRemoteDir(loc sshLocation, dest string) error {
+	script := fmt.Sprintf("cd %s 2>/dev/null || exit 0; "+
+		"find . -maxdepth 1 ! -type d \\( -name '*.conf' -o -name '*.conf.gz' -o -name state.json \\) | tar -chf - -T -",
+		shellQuote(loc.path))
+
+	// BatchMode fails fast instead of prompting for a password
+	args := []string{"-o", "BatchMode=yes"}
+	if loc.port != "" {
+		args = append(args, "-p", loc.port)
+	}
+	cmd := exec.Command("ssh", append(args, loc.dest, "--", script)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	extractErr := extractConfigs(out, dest)
+	io.Copy(io.Discard, out)
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("ssh %s: %v: %s", loc.dest, err, strings.TrimSpace(stderr.String()))
+	}
+	return extractErr
+}
+
+// extractConfigs writes the files of a flat tar stream into dir
+func extractConfigs(r io.Reader, dir string) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name := filepath.Base(filepath.FromSlash(header.Name))
+		if header.Typeflag != tar.TypeReg || name != filepath.Clean(strings.TrimPrefix(header.Name, "./")) {
+			continue
+		}
+
+		path := filepath.Join(dir, name)
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(f, tr)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+		os.Chtimes(path, header.ModTime, header.ModTime)
+	}
+}
+
+// fetchRemoteDirs replaces ssh:// NGINX_DIR and BACKUP_DIR settings with
+// local copies fetched over ssh, which are removed on exit. Commands that
+// would write to them are refused.
+func fetchRemoteDirs(command string) {
+	for _, dir := range []*string{&cfg.NginxDir, &cfg.BackupDir} {
+		loc, ok, err := parseRemoteDir(*dir)
+		if err != nil {
+			fail(ErrInvalid, "Error: %v", err)
+		}
+		if !ok {
+			continue
+		}
+		if !readsRemote[command] {
+			fail(ErrInvalid, "Error: %s is remote; only list can read ssh:// directories", *dir)
+		}
+
+		local, err := os.MkdirTemp("", "conf-mover-remote-")
+		if err != nil {
+			fail(codeOf(err), "Error creating temp directory: %v", err)
+		}
+		atExit = append(atExit, func() { os.RemoveAll(local) })
+		if err := fetchRemoteDir(loc, local); err != nil {
+			fail(ErrIO, "Error fetching %s: %v", *dir, err)
+		}
+
+		// loadEnv derives the state file from BACKUP_DIR; read the fetched one
+		if dir == &cfg.BackupDir && cfg.StateFile == filepath.Join(*dir, "state.json") {
+			cfg.StateFile = filepath.Join(local, "state.json")
+		}
+		remoteDirs = append(remoteDirs, remoteDir{url: *dir, local: local})
+		*dir = local
+	}
+}
+
+// isFetched reports whether dir is a fetched copy of an ssh:// directory
+func isFetched(dir string) bool {
+	for _, remote := range remoteDirs {
+		if dir == remote.local {
+			return true
+		}
+	}
+	return false
+}
+
+// remotePath maps a path inside a fetched copy back to its ssh:// location
+func remotePath(path string) string {
+	for _, dir := range remoteDirs {
+		if path == dir.local {
+			return dir.url
+		}
+		if strings.HasPrefix(path, dir.local+string(filepath.Separator)) {
+			return strings.TrimSuffix(dir.url, "/") + "/" + filepath.ToSlash(path[len(dir.local)+1:])
+		}
+	}
+	return path
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+var confNameRe = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9._-]*\.conf$`)
+
+// validateConfName rejects names that aren't a plain, safe .conf filename
+func validateConfName(name string) error {
+	if !confNameRe.MatchString(name) {
+		return withCode(ErrInvalid, fmt.Errorf("invalid config name %q: use letters, digits, '.', '_' and '-', ending in .conf", name))
+	}
+	return nil
+}
+
+// 10. Rename Functionality - Rename a vhost everywhere it lives
+func handleRename(args []string) {
+	if len(args) != 2 {
+		fail(ErrUsage, "Usage: ./conf-mover rename [old.conf] [new.conf]")
+	}
+
+	oldName := confFilename(args[0])
+	newName := args[1]
+	if filepath.Ext(newName) == "" {
+		newName += ".conf"
+	}
+	if err := validateConfName(newName); err != nil {
+		fail(codeOf(err), "Error: %v", err)
+	}
+	if oldName == newName {
+		fail(ErrInvalid, "Error: old and new names are the same")
+	}
+
+	// Check every location before touching anything
+	locations := [][2]string{
+		{filepath.Join(cfg.NginxDir, oldName), filepath.Join(cfg.NginxDir, newName)},
+		{filepath.Join(cfg.BackupDir, oldName), filepath.Join(cfg.BackupDir, newName)},
+		{versionDir(oldName), versionDir(newName)},
+	}
+	var renames [][2]string
+	for i, location := range locations {
+		if _, err := os.Lstat(location[1]); err == nil {
+			fail(ErrDstExists, "Error: %s already exists", location[1])
+		}
+		if _, err := os.Lstat(location[0]); err == nil {
+			renames = append(renames, location)
+		} else if i == 1 && len(renames) == 0 {
+			// Versions alone are history, not a config
+			fail(ErrNotFound, "Error: %s not found in %s or %s", oldName, cfg.NginxDir, cfg.BackupDir)
+		}
+	}
+
+	// A config enabled by ENABLE_MODE=symlink links to its backup, which
+	// is about to move
+	live, backup := locations[0][1], locations[1][1]
+	target, err := os.Readlink(locations[0][0])
+	oldBackup, _ := filepath.Abs(locations[1][0])
+	relink := err == nil && target == oldBackup
+
+	for i, rename := range renames {
+		if simulate {
+			simulated("rename %s -> %s", rename[0], rename[1])
+			continue
+		}
+		if err := os.Rename(rename[0], rename[1]); err != nil {
+			// Put back what we already moved so the name stays consistent
+			for _, done := range renames[:i] {
+				os.Rename(done[1], done[0])
+			}
+			fail(codeOf(err), "Error renaming %s: %v", rename[0], err)
+		}
+	}
+
+	for _, rename := range renames {
+		fmt.Printf("Success: renamed %s -> %s\n", rename[0], rename[1])
+	}
+
+	if relink {
+		if simulate {
+			simulated("link %s -> %s", live, backup)
+		} else if err := linkConf(backup, live); err != nil {
+			fmt.Printf("⚠ WARNING: %s still links to %s, re-link it by hand: %v\n", live, oldBackup, err)
+		}
+	}
+
+	// Pins, tags, reload hashes and scheduled re-enables belong to the
+	// config, not the name
+	state, err := loadState()
+	if err == nil {
+		changed := false
+		if hash, ok := state.LastReload[oldName]; ok {
+			state.LastReload[newName] = hash
+			delete(state.LastReload, oldName)
+			changed = true
+		}
+		if at, ok := state.ReenableAt[oldName]; ok {
+			state.ReenableAt[newName] = at
+			delete(state.ReenableAt, oldName)
+			changed = true
+		}
+		if state.Pinned[oldName] {
+			state.Pinned[newName] = true
+			delete(state.Pinned, oldName)
+			changed = true
+		}
+		if tags, ok := state.Tags[oldName]; ok {
+			state.Tags[newName] = tags
+			delete(state.Tags, oldName)
+			changed = true
+		}
+		if changed {
+			err = saveState(state)
+		}
+	}
+	if err != nil {
+		fmt.Printf("⚠ WARNING: state of %s (pin, tags, schedule) was not carried over: %v\n", oldName, err)
+	}
+}
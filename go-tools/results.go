@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// OpResult is the outcome of one step of a bulk operation
+type OpResult struct {
+	Filename string    `json:"filename"`
+	Action   string    `json:"action"`
+	Status   string    `json:"status"` // ok, failed, skipped or planned
+	Error    string    `json:"error,omitempty"`
+	Code     errorCode `json:"code,omitempty"` // Set when failed, see errcodes.go
+}
+
+// resultCollector gathers per-file results for bulk commands. With JSON
+// output the usual progress lines are sent to stderr and stdout carries only
+// the array of results, written by flush.
+type resultCollector struct {
+	json    bool
+	stdout  *os.File
+	results []OpResult
+}
+
+func newResultCollector(output string) *resultCollector {
+	if output != "text" && output != "json" {
+		fail(ErrInvalid, "Error: unknown output format %q, use text or json", output)
+	}
+
+	c := &resultCollector{json: output == "json", stdout: os.Stdout, results: []OpResult{}}
+	if c.json {
+		os.Stdout = os.Stderr
+	}
+	return c
+}
+
+// add records the outcome of one step; a non-nil err marks it failed
+func (c *resultCollector) add(filename, action string, err error) {
+	result := OpResult{Filename: filename, Action: action, Status: "ok"}
+	if err != nil {
+		result.Status = "failed"
+		result.Error = err.Error()
+		result.Code = codeOf(err)
+	}
+	c.results = append(c.results, result)
+}
+
+// addStatus records a step that didn't run, e.g. skipped or planned
+func (c *resultCollector) addStatus(filename, action, status string) {
+	c.results = append(c.results, OpResult{Filename: filename, Action: action, Status: status})
+}
+
+// failed counts the failed steps
+func (c *resultCollector) failed() int {
+	n := 0
+	for _, result := range c.results {
+		if result.Status == "failed" {
+			n++
+		}
+	}
+	return n
+}
+
+// flush writes the JSON results, if that's the output format, and puts
+// stdout back
+func (c *resultCollector) flush() {
+	os.Stdout = c.stdout
+	if !c.json {
+		return
+	}
+	jsonOutput, _ := json.MarshalIndent(c.results, "", "  ")
+	fmt.Println(string(jsonOutput))
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+// 46. Safe Reload Functionality - Reload, and roll back if nginx stops serving
+func handleSafeReload(args []string) {
+	fs := flag.NewFlagSet("safe-reload", flag.ExitOnError)
+	healthURL := fs.String("health-url", "", "URL that must answer without a 5xx after the reload")
+	timeout := fs.Duration("timeout", 30*time.Second, "how long to wait for nginx to become healthy")
+	rollbackTo := fs.String("rollback-to", "", "snapshot to restore on failure (default: the last one that reloaded healthy)")
+	parseFlags(fs, args)
+
+	state, err := loadState()
+	if err != nil {
+		fail(codeOf(err), "Error reading state file: %v", err)
+	}
+	good := *rollbackTo
+	if good == "" {
+		good = state.GoodSnapshot
+	}
+
+	// 1. Snapshot what is about to go live
+	snapshot, err := createSnapshot()
+	if err != nil {
+		fail(codeOf(err), "❌ Failed to snapshot config directory: %v", err)
+	}
+	fmt.Printf("✓ Snapshot saved to %s\n", snapshot)
+
+	// 2. Test and reload; a failed test leaves nginx on the old config
+	result := runReload()
+	if err := reportReload(result); err != nil {
+		fail(codeOf(err), "❌ %v", err)
+	}
+
+	// 3. Make sure nginx is still serving
+	err = waitHealthy("", cfg.Services, *healthURL, *timeout)
+	if err == nil {
+		fmt.Println("✓ Nginx is up and healthy")
+		state, err := loadState()
+		if err == nil {
+			state.GoodSnapshot = snapshot
+			err = saveState(state)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not record the healthy snapshot: %v\n", err)
+		}
+		return
+	}
+	fmt.Println(errorLine(ErrHealth, fmt.Sprintf("❌ Nginx reloaded but is %v", err)))
+
+	// 4. Roll back to the last configuration known to serve
+	if good == "" {
+		fail(ErrHealth, "⚠ WARNING: no healthy snapshot to roll back to, nginx is running the new config")
+	}
+	if err := restoreSnapshot(good); err != nil {
+		fail(codeOf(err), "⚠ WARNING: restoring %s failed, fix the config by hand: %v", good, err)
+	}
+	fmt.Printf("↩ Restored %s\n", good)
+
+	if err := reloadNginx(); err != nil {
+		fail(codeOf(err), "❌ %v\n⚠ WARNING: the previous config was restored but nginx was NOT reloaded", err)
+	}
+	if err := waitHealthy("", cfg.Services, *healthURL, *timeout); err != nil {
+		fail(ErrHealth, "⚠ WARNING: rolled back but nginx is still %v", err)
+	}
+	fail(ErrHealth, "↩ Rolled back to %s, nginx is healthy again; the attempted config is in %s", good, snapshot)
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// nameMatches reports whether a server_name would accept requests for host
+func nameMatches(name ServerName, host string) bool {
+	pattern := strings.ToLower(name.Name)
+	switch name.Type {
+	case "exact":
+		return pattern == host
+	case "wildcard":
+		switch {
+		case strings.HasPrefix(pattern, "*."):
+			return strings.HasSuffix(host, pattern[1:])
+		case strings.HasPrefix(pattern, "."):
+			// ".example.com" is example.com plus *.example.com
+			return host == pattern[1:] || strings.HasSuffix(host, pattern)
+		case strings.HasSuffix(pattern, ".*"):
+			return strings.HasPrefix(host, pattern[:len(pattern)-1])
+		}
+	case "regex":
+		// nginx uses PCRE; names Go can't compile are left alone
+		re, err := regexp.Compile("(?i)" + name.Name[1:])
+		return err == nil && re.MatchString(host)
+	}
+	return false
+}
+
+// sampleHost is a hostname a wildcard name matches, used to probe whether
+// other names would also match it
+func sampleHost(name ServerName) string {
+	pattern := strings.ToLower(name.Name)
+	switch {
+	case strings.HasPrefix(pattern, "*."):
+		return "www" + pattern[1:]
+	case strings.HasPrefix(pattern, "."):
+		return "www" + pattern
+	case strings.HasSuffix(pattern, ".*"):
+		return pattern[:len(pattern)-1] + "com"
+	}
+	return pattern
+}
+
+// namePriority ranks names the way nginx chooses between them: exact
+// names first, then the longest leading wildcard, then the longest
+// trailing wildcard, then regexes in the order they appear. Lower wins.
+func namePriority(name ServerName) (rank, length int) {
+	switch {
+	case name.Type == "exact":
+		return 0, 0
+	case name.Type == "wildcard" && !strings.HasSuffix(name.Name, ".*"):
+		return 1, -len(name.Name)
+	case name.Type == "wildcard":
+		return 2, -len(name.Name)
+	}
+	return 3, 0
+}
+
+// listenAddresses returns the addresses file listens on, with nginx's
+// default when it has no listen directive
+func listenAddresses(file FileData) map[string]bool {
+	addresses := map[string]bool{}
+	for _, listen := range file.listens {
+		addresses[listen.Address] = true
+	}
+	if len(addresses) == 0 {
+		addresses["*:80"] = true
+	}
+	return addresses
+}
+
+// checkServerNameShadowing flags names in different enabled configs that
+// would both match some hostname on a shared address, and says which
+// config nginx routes that hostname to. Identical names are left to the
+// duplicate-name checks.
+func checkServerNameShadowing(files []FileData, opts auditOptions) []Finding {
+	var enabled []FileData
+	for _, file := range files {
+		if file.enabled() && file.ParseError == "" {
+			enabled = append(enabled, file)
+		}
+	}
+
+	var findings []Finding
+	seen := map[string]bool{}
+	for i, a := range enabled {
+		for _, b := range enabled[i+1:] {
+			if !sharesAddress(listenAddresses(a), listenAddresses(b)) {
+				continue
+			}
+			for _, nameA := range a.ServerNames {
+				for _, nameB := range b.ServerNames {
+					host, ok := overlap(nameA, nameB)
+					if !ok {
+						continue
+					}
+
+					// Order the pair so winner is the one nginx picks
+					winner, winnerFile, loser, loserFile := nameA, a, nameB, b
+					rankA, lengthA := namePriority(nameA)
+					rankB, lengthB := namePriority(nameB)
+					if rankB < rankA || (rankB == rankA && lengthB < lengthA) {
+						winner, winnerFile, loser, loserFile = nameB, b, nameA, a
+					}
+					if rankA == rankB && lengthA == lengthB {
+						// Two regexes: the first one loaded wins, which
+						// depends on include order we can't see here
+						continue
+					}
+
+					key := loserFile.Filename + "\x00" + loser.Name + "\x00" + winnerFile.Filename
+					if seen[key] {
+						continue
+					}
+					seen[key] = true
+					findings = append(findings, Finding{loserFile.Filename, fmt.Sprintf(
+						"server_name %s is shadowed by %s in %s for requests like %s (%s beats %s)",
+						loser.Name, winner.Name, winnerFile.Filename, host, winner.Type, loser.Type)})
+				}
+			}
+		}
+	}
+	return findings
+}
+
+// sharesAddress reports whether two sets of listen addresses overlap
+func sharesAddress(a, b map[string]bool) bool {
+	for addr := range a {
+		if b[addr] {
+			return true
+		}
+	}
+	return false
+}
+
+// overlap finds a hostname both names match, if there is an obvious one
+func overlap(a, b ServerName) (string, bool) {
+	if strings.EqualFold(a.Name, b.Name) || a.Type == "default" || b.Type == "default" {
+		return "", false
+	}
+	for _, pair := range [][2]ServerName{{a, b}, {b, a}} {
+		if pair[0].Type == "regex" {
+			continue
+		}
+		host := sampleHost(pair[0])
+		if nameMatches(pair[1], host) {
+			return host, true
+		}
+	}
+	return "", false
+}
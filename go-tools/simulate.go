@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// simulate is set by the global --simulate flag. Moves, reloads and state
+// changes are then reported as "[simulate] ..." lines and succeed without
+// touching the filesystem or calling nginx or systemctl, so whole workflows
+// can be exercised against fixture directories. Commands that write must do
+// it through ensureDir, writeFileAtomic, removeFile, saveVersion or
+// moveConf, which all check it, and test configs through testNginx, which
+// passes without running nginx.
+var simulate bool
+
+// simulated reports an action that --simulate skipped. It goes to stderr
+// so JSON output on stdout stays the same as a real run.
+func simulated(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "[simulate] "+format+"\n", args...)
+}
+
+// ensureDir creates dir and its parents, unless simulating
+func ensureDir(dir string) error {
+	if simulate {
+		return nil
+	}
+	return os.MkdirAll(dir, 0755)
+}
+
+// removeFile deletes path, unless simulating
+func removeFile(path string) error {
+	if simulate {
+		simulated("remove %s", path)
+		return nil
+	}
+	return os.Remove(path)
+}
+
+// simulatedReload is the result a successful reload would have
+func simulatedReload(host string) ReloadResult {
+	where := ""
+	if host != "" {
+		where = " on " + host
+	}
+	simulated("nginx -t%s", where)
+
+	result := ReloadResult{Host: host, TestPassed: true, TestOutput: "simulated", Reloaded: true}
+	for _, service := range cfg.Services {
+		simulated("systemctl reload %s%s", service, where)
+		result.Services = append(result.Services, ServiceResult{Service: service, Reloaded: true, Output: "simulated"})
+	}
+	return result
+}
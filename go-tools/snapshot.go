@@ -0,0 +1,312 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// createSnapshot archives the whole NginxDir into a timestamped .tar.gz in
+// SnapshotDir and returns the archive path
+func createSnapshot() (string, error) {
+	if simulate {
+		simulated("snapshot %s into %s", cfg.NginxDir, cfg.SnapshotDir)
+		return filepath.Join(cfg.SnapshotDir, "simulated.tar.gz"), nil
+	}
+	if err := os.MkdirAll(cfg.SnapshotDir, 0755); err != nil {
+		return "", err
+	}
+
+	// Nanoseconds since safe-reload snapshots right before it may roll back
+	// to one from the same second; O_EXCL so one can never replace another
+	name := fmt.Sprintf("nginx-%s.tar.gz", time.Now().Format("20060102-150405.000000000"))
+	path := filepath.Join(cfg.SnapshotDir, name)
+
+	out, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+
+	err = filepath.WalkDir(cfg.NginxDir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// Symlinks are kept as links, they are how ENABLE_MODE=symlink
+		// enables a site
+		link := ""
+		switch {
+		case d.Type()&fs.ModeSymlink != 0:
+			if link, err = os.Readlink(file); err != nil {
+				return err
+			}
+		case !d.Type().IsRegular():
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(cfg.NginxDir, file)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+
+		if err := tw.WriteHeader(header); err != nil || link != "" {
+			return err
+		}
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		os.Remove(path)
+		return "", err
+	}
+
+	if err := tw.Close(); err != nil {
+		return "", err
+	}
+	if err := gz.Close(); err != nil {
+		return "", err
+	}
+	return path, out.Close()
+}
+
+// snapshotHashes reads a snapshot tarball in memory and hashes each file
+func snapshotHashes(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	defer gz.Close()
+
+	hashes := map[string]string{}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return hashes, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		if header.Typeflag == tar.TypeSymlink {
+			// A link changes when its target does
+			hashes[header.Name] = "-> " + header.Linkname
+			continue
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		h := sha256.New()
+		if _, err := io.Copy(h, tr); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		hashes[header.Name] = hex.EncodeToString(h.Sum(nil))
+	}
+}
+
+// SnapshotDiff lists the files that differ between two snapshots
+type SnapshotDiff struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	Changed []string `json:"changed"`
+}
+
+// diffSnapshots compares two snapshots file by file
+func diffSnapshots(oldPath, newPath string) (SnapshotDiff, error) {
+	diff := SnapshotDiff{Added: []string{}, Removed: []string{}, Changed: []string{}}
+
+	before, err := snapshotHashes(oldPath)
+	if err != nil {
+		return diff, err
+	}
+	after, err := snapshotHashes(newPath)
+	if err != nil {
+		return diff, err
+	}
+
+	for name, hash := range after {
+		if oldHash, ok := before[name]; !ok {
+			diff.Added = append(diff.Added, name)
+		} else if oldHash != hash {
+			diff.Changed = append(diff.Changed, name)
+		}
+	}
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			diff.Removed = append(diff.Removed, name)
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Changed)
+	return diff, nil
+}
+
+// handleSnapshotDiff reports what changed between two snapshots
+func handleSnapshotDiff(args []string) {
+	fs := flag.NewFlagSet("snapshot diff", flag.ExitOnError)
+	output := fs.String("output", "text", "output format: text or json")
+	positional := parseFlags(fs, args)
+
+	if len(positional) != 2 {
+		fail(ErrUsage, "Usage: ./conf-mover snapshot diff [old.tar.gz] [new.tar.gz] [--output=json]")
+	}
+
+	diff, err := diffSnapshots(positional[0], positional[1])
+	if err != nil {
+		fail(codeOf(err), "Error reading snapshot: %v", err)
+	}
+
+	if *output == "json" {
+		jsonOutput, _ := json.MarshalIndent(diff, "", "  ")
+		fmt.Println(string(jsonOutput))
+		return
+	}
+
+	if len(diff.Added)+len(diff.Removed)+len(diff.Changed) == 0 {
+		fmt.Println("✓ Snapshots are identical")
+		return
+	}
+	for _, name := range diff.Added {
+		fmt.Printf("  added      %s\n", name)
+	}
+	for _, name := range diff.Removed {
+		fmt.Printf("  removed    %s\n", name)
+	}
+	for _, name := range diff.Changed {
+		fmt.Printf("  changed    %s\n", name)
+	}
+}
+
+// 5. Snapshot Functionality - Point-in-time copy of the config directory
+func handleSnapshot(args []string) {
+	if len(args) > 0 && args[0] == "diff" {
+		handleSnapshotDiff(args[1:])
+		return
+	}
+
+	path, err := createSnapshot()
+	if err != nil {
+		fail(codeOf(err), "Error creating snapshot: %v", err)
+	}
+
+	fmt.Printf("Success: snapshot written to %s\n", path)
+}
+
+// restoreSnapshot puts NginxDir back to exactly what the snapshot holds:
+// every archived file is rewritten and files the snapshot doesn't know
+// about are removed
+func restoreSnapshot(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	defer gz.Close()
+
+	restored := map[string]bool{}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeSymlink {
+			continue
+		}
+
+		// Never write outside NginxDir, whatever the archive says
+		name := filepath.FromSlash(header.Name)
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("%s: unsafe path %q in snapshot", path, header.Name)
+		}
+		dst := filepath.Join(cfg.NginxDir, name)
+		if err := ensureDir(filepath.Dir(dst)); err != nil {
+			return err
+		}
+
+		if header.Typeflag == tar.TypeSymlink {
+			if err := restoreLink(header.Linkname, dst); err != nil {
+				return err
+			}
+			restored[dst] = true
+			continue
+		}
+
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		if err := writeFileAtomic(dst, content); err != nil {
+			return err
+		}
+		restored[dst] = true
+	}
+
+	return filepath.WalkDir(cfg.NginxDir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if (d.Type().IsRegular() || d.Type()&fs.ModeSymlink != 0) && !restored[file] {
+			return removeFile(file)
+		}
+		return nil
+	})
+}
+
+// restoreLink makes dst a symlink to target, replacing whatever is there
+func restoreLink(target, dst string) error {
+	if simulate {
+		simulated("link %s -> %s", dst, target)
+		return nil
+	}
+	if current, err := os.Readlink(dst); err == nil && current == target {
+		return nil
+	}
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Symlink(target, dst)
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// blockStart moves start back to the beginning of its line and over any
+// comment lines directly above, so a block keeps the comments that describe
+// it
+func blockStart(content string, start int) int {
+	start = strings.LastIndexByte(content[:start], '\n') + 1
+	for start > 0 {
+		prev := strings.LastIndexByte(content[:start-1], '\n') + 1
+		if !strings.HasPrefix(strings.TrimSpace(content[prev:start]), "#") {
+			break
+		}
+		start = prev
+	}
+	return start
+}
+
+// splitName picks a filename for a server block from its first usable
+// server_name, falling back to base-N
+func splitName(d directive, directives []directive, base string, n int) string {
+	for _, other := range directives {
+		if other.name != "server_name" || other.start < d.start || other.end > d.end || !other.in("server") {
+			continue
+		}
+		for _, name := range other.args {
+			sn := classifyServerName(name)
+			if sn.Type != "exact" && sn.Type != "wildcard" {
+				continue
+			}
+			candidate := strings.Trim(strings.TrimPrefix(strings.TrimSuffix(name, ".*"), "*."), ".") + ".conf"
+			if validateConfName(candidate) == nil {
+				return candidate
+			}
+		}
+	}
+	return fmt.Sprintf("%s-%d.conf", base, n)
+}
+
+// 33. Split Functionality - One file per server block
+func handleSplit(args []string) {
+	fs := flag.NewFlagSet("split", flag.ExitOnError)
+	force := fs.Bool("force", false, "split a pinned config too")
+	positional := parseFlags(fs, args)
+
+	if len(positional) != 1 {
+		fail(ErrUsage, "Usage: ./conf-mover split [big.conf] [--force]")
+	}
+
+	filename := confFilename(positional[0])
+
+	// The original is moved to backup, which pins protect against
+	if !*force {
+		pinned, err := isPinned(filename)
+		if err != nil {
+			fail(codeOf(err), "Error: %v", err)
+		}
+		if pinned {
+			fail(ErrPinned, "Error: %s is pinned; unpin it or use --force", filename)
+		}
+	}
+
+	src := filepath.Join(cfg.NginxDir, filename)
+	data, err := os.ReadFile(src)
+	if err != nil {
+		fail(codeOf(err), "Error: %s is not an enabled config: %v", filename, err)
+	}
+	content := string(data)
+	base := strings.TrimSuffix(filename, ".conf")
+
+	// Cut out each top-level server block; whatever is left is shared
+	directives := parseDirectives(content)
+	outputs := map[string]string{}
+	var order []string
+	var shared strings.Builder
+	last := 0
+	for _, d := range directives {
+		if d.name != "server" || !d.block || len(d.context) > 0 {
+			continue
+		}
+		start := blockStart(content, d.start)
+		if start < last {
+			start = d.start
+		}
+		shared.WriteString(content[last:start])
+
+		name := splitName(d, directives, base, len(order)+1)
+		for i := 2; outputs[name] != "" || name == filename; i++ {
+			name = fmt.Sprintf("%s-%d.conf", strings.TrimSuffix(name, ".conf"), i)
+		}
+		outputs[name] = strings.TrimRight(content[start:d.end], " \t") + "\n"
+		order = append(order, name)
+		last = d.end
+	}
+	shared.WriteString(content[last:])
+
+	if len(order) < 2 {
+		fail(ErrInvalid, "Error: %s has %d server block(s), nothing to split", filename, len(order))
+	}
+
+	// Directives outside any server block go in a file of their own; copying
+	// them into every output would define things like upstreams twice
+	topLevel := 0
+	for _, d := range parseDirectives(shared.String()) {
+		if len(d.context) == 0 {
+			topLevel++
+		}
+	}
+	if topLevel > 0 {
+		name := base + "-shared.conf"
+		outputs[name] = strings.TrimSpace(shared.String()) + "\n"
+		order = append(order, name)
+		fmt.Printf("⚠ %d top-level directive(s) outside server blocks moved to %s\n", topLevel, name)
+	}
+
+	for _, name := range order {
+		if _, err := os.Lstat(filepath.Join(cfg.NginxDir, name)); err == nil {
+			fail(ErrDstExists, "Error: %s already exists", filepath.Join(cfg.NginxDir, name))
+		}
+	}
+
+	// Put things back the way they were if anything below fails
+	var written []string
+	rollback := func() {
+		for _, path := range written {
+			removeFile(path)
+		}
+		if _, err := os.Stat(src); os.IsNotExist(err) {
+			if _, _, err := moveConf("restore", filename); err != nil {
+				fail(codeOf(err), "❌ Rollback failed, restore %s by hand: %v", filename, err)
+			}
+		}
+		fmt.Printf("↩ Restored %s\n", filename)
+	}
+
+	for _, name := range order {
+		path := filepath.Join(cfg.NginxDir, name)
+		if err := writeFileAtomic(path, []byte(outputs[name])); err != nil {
+			fmt.Println(errorLine(codeOf(err), fmt.Sprintf("❌ Writing %s failed: %v", path, err)))
+			rollback()
+			exit(1)
+		}
+		written = append(written, path)
+	}
+	if _, _, err := moveConf("backup", filename); err != nil {
+		fmt.Println(errorLine(codeOf(err), fmt.Sprintf("❌ Backing up %s failed: %v", filename, err)))
+		rollback()
+		exit(1)
+	}
+
+	if output, err := testNginx(); err != nil {
+		fmt.Println(errorLine(ErrNginxTest, fmt.Sprintf("❌ Nginx config test failed:\n%s", output)))
+		rollback()
+		exit(1)
+	}
+	fmt.Println("✓ Nginx configuration test passed")
+
+	for _, name := range order {
+		fmt.Printf("Success: created %s\n", filepath.Join(cfg.NginxDir, name))
+	}
+	fmt.Printf("Success: %s backed up to %s\n", filename, cfg.BackupDir)
+}
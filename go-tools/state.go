@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// State is conf-mover's own bookkeeping, persisted as JSON in cfg.StateFile
+type State struct {
+	// LastReload maps each enabled filename to its content hash at the time
+	// of the last successful reload
+	LastReload map[string]string `json:"last_reload,omitempty"`
+
+	// ReenableAt maps temporarily disabled filenames to when resume should
+	// enable them again
+	ReenableAt map[string]time.Time `json:"reenable_at,omitempty"`
+
+	// Pinned holds the filenames disable refuses to touch without --force
+	Pinned map[string]bool `json:"pinned,omitempty"`
+
+	// Tags maps filenames to their key=value tags, see tag. Keyed by name,
+	// so they follow a config between NginxDir and BackupDir.
+	Tags map[string]map[string]string `json:"tags,omitempty"`
+
+	// GoodSnapshot is the last snapshot safe-reload saw serving healthily,
+	// what it rolls back to
+	GoodSnapshot string `json:"good_snapshot,omitempty"`
+}
+
+// loadState reads the state file. A missing file yields an empty State.
+func loadState() (State, error) {
+	var state State
+
+	data, err := os.ReadFile(cfg.StateFile)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+
+	err = json.Unmarshal(data, &state)
+	return state, err
+}
+
+// saveState writes the state file, replacing it atomically
+func saveState(state State) error {
+	if simulate {
+		simulated("update %s", cfg.StateFile)
+		return nil
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cfg.StateFile), 0755); err != nil {
+		return err
+	}
+
+	tmp := cfg.StateFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, cfg.StateFile)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// confStats are the size and shape numbers for one config
+type confStats struct {
+	Servers     int
+	Locations   int
+	Lines       int
+	ServerNames int
+	Includes    int
+}
+
+func (s *confStats) add(other confStats) {
+	s.Servers += other.Servers
+	s.Locations += other.Locations
+	s.Lines += other.Lines
+	s.ServerNames += other.ServerNames
+	s.Includes += other.Includes
+}
+
+// computeStats counts blocks and directives using the brace-tracking parser
+func computeStats(content string) confStats {
+	stats := confStats{Lines: strings.Count(content, "\n")}
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		stats.Lines++
+	}
+
+	for _, d := range parseDirectives(content) {
+		switch {
+		case d.name == "server" && d.block:
+			stats.Servers++
+		case d.name == "location" && d.block:
+			stats.Locations++
+		case d.name == "server_name" && d.in("server"):
+			stats.ServerNames += len(d.args)
+		case d.name == "include":
+			stats.Includes++
+		}
+	}
+	return stats
+}
+
+// 25. Stats Functionality - Complexity numbers per config
+func handleStats(args []string) {
+	var paths []string
+	if len(args) > 0 {
+		for _, name := range args {
+			path, err := findConf(name)
+			if err != nil {
+				fail(codeOf(err), "Error: %v", err)
+			}
+			paths = append(paths, path)
+		}
+	} else {
+		files, errs := scanConfigs()
+		for _, err := range errs {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		for _, file := range files {
+			if file.Skipped == "" && file.ParseError == "" {
+				paths = append(paths, file.Path)
+			}
+		}
+	}
+
+	row := "%-30s %7s %9s %6s %12s %8s\n"
+	fmt.Printf(row, "FILE", "SERVERS", "LOCATIONS", "LINES", "SERVER_NAMES", "INCLUDES")
+
+	var total confStats
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			continue
+		}
+		stats := computeStats(string(content))
+		total.add(stats)
+		fmt.Printf("%-30s %7d %9d %6d %12d %8d\n", confFilename(path),
+			stats.Servers, stats.Locations, stats.Lines, stats.ServerNames, stats.Includes)
+	}
+
+	if len(paths) < 2 {
+		return
+	}
+
+	n := float64(len(paths))
+	fmt.Println()
+	fmt.Printf("%-30s %7d %9d %6d %12d %8d\n", fmt.Sprintf("TOTAL (%d files)", len(paths)),
+		total.Servers, total.Locations, total.Lines, total.ServerNames, total.Includes)
+	fmt.Printf("%-30s %7.1f %9.1f %6.1f %12.1f %8.1f\n", "AVERAGE",
+		float64(total.Servers)/n, float64(total.Locations)/n, float64(total.Lines)/n,
+		float64(total.ServerNames)/n, float64(total.Includes)/n)
+}
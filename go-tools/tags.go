@@ -0,0 +1,104 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// parseTag splits "team=payments" into its key and value. A bare "team"
+// has an empty value.
+func parseTag(tag string) (key, value string) {
+	key, value, _ = strings.Cut(tag, "=")
+	return strings.TrimSpace(key), strings.TrimSpace(value)
+}
+
+// matchesTags reports whether tags satisfy every filter. A filter without
+// a value only needs the key to be present.
+func matchesTags(tags map[string]string, filters []string) bool {
+	for _, filter := range filters {
+		key, value := parseTag(filter)
+		got, ok := tags[key]
+		if !ok || (strings.Contains(filter, "=") && got != value) {
+			return false
+		}
+	}
+	return true
+}
+
+// formatTags renders tags as sorted key=value pairs
+func formatTags(tags map[string]string) string {
+	var pairs []string
+	for key, value := range tags {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, " ")
+}
+
+// 43. Tag Functionality - Attach metadata to configs without editing them
+func handleTag(args []string) {
+	fs := flag.NewFlagSet("tag", flag.ExitOnError)
+	var remove stringList
+	fs.Var(&remove, "remove", "tag key to remove (repeatable or comma-separated)")
+	positional := parseFlags(fs, args)
+
+	if len(positional) == 0 {
+		fail(ErrUsage, "Usage: ./conf-mover tag [filename] [key=value...] [--remove=key]")
+	}
+
+	filename := confFilename(positional[0])
+	if _, err := findConf(filename); err != nil {
+		fail(codeOf(err), "Error: %v", err)
+	}
+
+	state, err := loadState()
+	if err != nil {
+		fail(codeOf(err), "Error reading state file: %v", err)
+	}
+
+	// With nothing to change, show the current tags
+	if len(positional) == 1 && len(remove) == 0 {
+		if len(state.Tags[filename]) == 0 {
+			fmt.Printf("%s has no tags\n", filename)
+			return
+		}
+		fmt.Printf("%s: %s\n", filename, formatTags(state.Tags[filename]))
+		return
+	}
+
+	if state.Tags == nil {
+		state.Tags = map[string]map[string]string{}
+	}
+	tags := state.Tags[filename]
+	if tags == nil {
+		tags = map[string]string{}
+	}
+
+	for _, tag := range positional[1:] {
+		key, value := parseTag(tag)
+		if key == "" || !strings.Contains(tag, "=") {
+			fail(ErrInvalid, "Error: invalid tag %q, use key=value", tag)
+		}
+		tags[key] = value
+	}
+	for _, key := range remove {
+		delete(tags, key)
+	}
+
+	if len(tags) == 0 {
+		delete(state.Tags, filename)
+	} else {
+		state.Tags[filename] = tags
+	}
+
+	if err := saveState(state); err != nil {
+		fail(codeOf(err), "Error writing state file: %v", err)
+	}
+	if len(tags) == 0 {
+		fmt.Printf("Success: removed every tag from %s\n", filename)
+		return
+	}
+	fmt.Printf("Success: %s tagged %s\n", filename, formatTags(tags))
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// testConfFile checks a single config with nginx -t, so it can be validated
+// before it goes live. The nginx prefix (the parent of NginxDir) is copied
+// to a throwaway directory with the file in NginxDir under its own name, so
+// it is tested against the real nginx.conf, mime.types and snippets and the
+// upstreams and maps other configs define. Where the prefix has no
+// nginx.conf, the file is wrapped in a minimal one and included inside
+// http {} the same way the sites directory would be.
+func testConfFile(path string) ([]byte, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "conf-mover-test-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	prefix := filepath.Dir(cfg.NginxDir)
+	if _, err := os.Stat(filepath.Join(prefix, "nginx.conf")); err == nil && !isGlobPattern(cfg.NginxDir) {
+		if err := copyPrefix(prefix, dir); err != nil {
+			return nil, fmt.Errorf("copying %s: %w", prefix, err)
+		}
+
+		// Replace the live copy, or a link to it, rather than write through it
+		dst := filepath.Join(dir, filepath.Base(cfg.NginxDir), confFilename(path))
+		os.Remove(dst)
+		if err := os.WriteFile(dst, repointPaths(content, prefix, dir), 0644); err != nil {
+			return nil, err
+		}
+		return exec.Command("nginx", "-t", "-p", dir+"/", "-c", filepath.Join(dir, "nginx.conf")).CombinedOutput()
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	harness := fmt.Sprintf("pid %q;\nerror_log %q;\nevents {}\nhttp {\n    include %q;\n}\n",
+		filepath.Join(dir, "nginx.pid"), filepath.Join(dir, "error.log"), abs)
+	conf := filepath.Join(dir, "nginx.conf")
+	if err := os.WriteFile(conf, []byte(harness), 0644); err != nil {
+		return nil, err
+	}
+
+	return exec.Command("nginx", "-t", "-c", conf).CombinedOutput()
+}
+
+// copyPrefix copies the nginx prefix src into dst for testConfFile. Absolute
+// paths into src are pointed at dst so includes like /etc/nginx/conf.d/*.conf
+// pick up the copy. Links are kept as they are, so one into BackupDir (see
+// ENABLE_MODE=symlink) still resolves; our own backups, versions and
+// snapshots aren't copied.
+func copyPrefix(src, dst string) error {
+	skip := map[string]bool{}
+	for _, dir := range []string{cfg.BackupDir, cfg.VersionsDir, cfg.SnapshotDir} {
+		skip[canonicalPath(dir)] = true
+	}
+
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case d.IsDir():
+			if path != src && skip[canonicalPath(path)] {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(target, 0755)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case d.Type().IsRegular():
+			// Keep modes, there may be private keys in here
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			return os.WriteFile(target, repointPaths(content, src, dst), info.Mode().Perm())
+		}
+		return nil
+	})
+}
+
+// repointPaths rewrites absolute paths under from to the same paths under to
+func repointPaths(content []byte, from, to string) []byte {
+	from, to = filepath.Clean(from)+"/", filepath.Clean(to)+"/"
+	return bytes.ReplaceAll(content, []byte(from), []byte(to))
+}
+
+// 22. Test File Functionality - Validate one config on its own
+func handleTestFile(args []string) {
+	if len(args) != 1 {
+		fail(ErrUsage, "Usage: ./conf-mover test-file [path/to/file.conf]")
+	}
+
+	output, err := testConfFile(args[0])
+	if err != nil {
+		fmt.Printf("❌ %s failed validation:\n%s", args[0], output)
+		if len(output) == 0 {
+			fmt.Printf("%v\n", err)
+		}
+		exit(1)
+	}
+	fmt.Printf("✓ %s is valid\n", args[0])
+}
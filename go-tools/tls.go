@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// weakProtocols are TLS versions our baseline no longer allows
+var weakProtocols = map[string]bool{"SSLv2": true, "SSLv3": true, "TLSv1": true, "TLSv1.1": true}
+
+// weakCipherTerms are OpenSSL cipher string components that enable broken
+// or unauthenticated ciphers when they aren't excluded with ! or -
+var weakCipherTerms = []string{"NULL", "EXPORT", "EXP", "RC4", "DES", "MD5", "ADH", "AECDH", "LOW", "anon"}
+
+// weakCiphers returns the parts of an OpenSSL cipher string that let weak
+// ciphers in
+func weakCiphers(spec string) []string {
+	var weak []string
+	for _, part := range strings.FieldsFunc(spec, func(r rune) bool { return r == ':' || r == ',' || r == ' ' }) {
+		if strings.HasPrefix(part, "!") || strings.HasPrefix(part, "-") {
+			continue
+		}
+		part = strings.TrimPrefix(part, "+")
+		for _, term := range weakCipherTerms {
+			if strings.Contains(strings.ToUpper(part), strings.ToUpper(term)) {
+				weak = append(weak, part)
+				break
+			}
+		}
+	}
+	return weak
+}
+
+// 31. TLS Audit Functionality - Vhosts allowing old protocols or ciphers
+func handleTLSAudit() {
+	files, errs := scanConfigs()
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	problems := 0
+	for _, file := range files {
+		if !file.enabled() {
+			continue
+		}
+		for _, setting := range file.tls {
+			var weak []string
+			if setting.Directive == "ssl_protocols" {
+				for _, protocol := range setting.Args {
+					if weakProtocols[protocol] {
+						weak = append(weak, protocol)
+					}
+				}
+			} else {
+				weak = weakCiphers(strings.Join(setting.Args, " "))
+			}
+
+			if len(weak) > 0 {
+				fmt.Printf("❌ %s:%d: %s allows %s\n", file.Filename, setting.Line, setting.Directive, strings.Join(weak, ", "))
+				problems++
+			}
+		}
+	}
+
+	if problems == 0 {
+		fmt.Println("✓ No weak TLS settings found")
+		return
+	}
+	fmt.Printf("%d weak TLS setting(s) found\n", problems)
+	exit(1)
+}
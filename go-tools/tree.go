@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// domainOf returns the last two labels of a host name, which is the
+// customer domain for the usual example.com style names. Wildcard prefixes
+// and suffixes are dropped first.
+func domainOf(name string) string {
+	name = strings.TrimPrefix(name, "*.")
+	name = strings.TrimPrefix(name, ".")
+	name = strings.TrimSuffix(name, ".*")
+
+	labels := strings.Split(name, ".")
+	if len(labels) <= 2 {
+		return name
+	}
+	return strings.Join(labels[len(labels)-2:], ".")
+}
+
+// 24. Tree Functionality - Vhosts grouped by domain
+func handleTree() {
+	files, errs := scanConfigs()
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	// domain -> host name -> files serving it
+	tree := map[string]map[string][]FileData{}
+	var unnamed []FileData
+	for _, file := range files {
+		named := false
+		for _, name := range file.ServerNames {
+			// Regexes and catch-alls don't belong to any one domain
+			if name.Type != "exact" && name.Type != "wildcard" {
+				continue
+			}
+			domain := domainOf(name.Name)
+			if tree[domain] == nil {
+				tree[domain] = map[string][]FileData{}
+			}
+			tree[domain][name.Name] = append(tree[domain][name.Name], file)
+			named = true
+		}
+		if !named {
+			unnamed = append(unnamed, file)
+		}
+	}
+
+	printFiles := func(indent string, files []FileData) {
+		for _, file := range files {
+			marker := "✓"
+			if !file.enabled() {
+				marker = "○"
+			}
+			fmt.Printf("%s%s %s\n", indent, marker, file.Filename)
+		}
+	}
+
+	var domains []string
+	for domain := range tree {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+
+	for _, domain := range domains {
+		fmt.Println(domain)
+
+		var hosts []string
+		for host := range tree[domain] {
+			hosts = append(hosts, host)
+		}
+		sort.Strings(hosts)
+
+		for _, host := range hosts {
+			fmt.Printf("  %s\n", host)
+			printFiles("    ", tree[domain][host])
+		}
+	}
+
+	if len(unnamed) > 0 {
+		fmt.Println("(no server_name)")
+		printFiles("  ", unnamed)
+	}
+
+	fmt.Println("\n✓ enabled  ○ disabled")
+}
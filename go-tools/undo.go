@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// newestBackup returns the most recently disabled config in BackupDir.
+// With ENABLE_MODE copy or symlink BackupDir also holds the canonical copy
+// of every enabled config, so those are skipped.
+func newestBackup() (string, time.Time, error) {
+	paths, err := confPaths(cfg.BackupDir)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	var newest string
+	var newestTime time.Time
+	for _, path := range paths {
+		if _, err := os.Lstat(filepath.Join(cfg.NginxDir, confFilename(path))); err == nil {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if newest == "" || info.ModTime().After(newestTime) {
+			newest, newestTime = path, info.ModTime()
+		}
+	}
+	return newest, newestTime, nil
+}
+
+// 30. Undo Functionality - Re-enable whatever was disabled last
+func handleUndo(args []string) {
+	fs := flag.NewFlagSet("undo", flag.ExitOnError)
+	yes := fs.Bool("yes", false, "don't ask for confirmation")
+	revert := fs.Bool("revert-on-failure", false, "disable again if the config test fails")
+	parseFlags(fs, args)
+
+	path, disabledAt, err := newestBackup()
+	if err != nil {
+		fail(codeOf(err), "Error reading backup directory: %v", err)
+	}
+	if path == "" {
+		fmt.Printf("Nothing to undo, %s has no disabled configs\n", cfg.BackupDir)
+		return
+	}
+
+	filename := confFilename(path)
+	fmt.Printf("Most recent backup: %s (%s)\n", filename, relativeTime(disabledAt, time.Now()))
+	if !*yes && !confirm(fmt.Sprintf("Enable %s and reload nginx?", filename)) {
+		fmt.Println("Cancelled")
+		return
+	}
+
+	src, dst, err := moveConf("restore", filename)
+	if err != nil {
+		fail(codeOf(err), "Error: %v", err)
+	}
+	fmt.Printf("Success: %s moved %s -> %s\n", filename, src, dst)
+
+	if err := setReenable(filename, time.Time{}); err != nil {
+		fmt.Printf("Warning: could not update state file: %v\n", err)
+	}
+	if err := reloadAfterMove("restore", []string{filename}, *revert); err != nil {
+		exit(1)
+	}
+}
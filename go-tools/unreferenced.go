@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// canonicalPath makes two paths to the same file compare equal
+func canonicalPath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return path
+}
+
+// 49. Unreferenced Functionality - Enabled configs nginx never loads
+func handleUnreferenced(args []string) {
+	fs := flag.NewFlagSet("unreferenced", flag.ExitOnError)
+	root := fs.String("root", "/etc/nginx/nginx.conf", "main nginx config to follow includes from")
+	parseFlags(fs, args)
+
+	if _, err := os.Stat(*root); err != nil {
+		fail(codeOf(err), "Error: %v", err)
+	}
+
+	// nginx resolves relative includes from its prefix, the directory of
+	// nginx.conf
+	loaded := map[string]bool{canonicalPath(*root): true}
+	for _, edge := range includeGraph([]string{*root}, filepath.Dir(*root)) {
+		if !edge.unresolved {
+			loaded[canonicalPath(edge.to)] = true
+		}
+	}
+
+	paths, err := confPaths(cfg.NginxDir)
+	if err != nil {
+		fail(codeOf(err), "Error reading nginx directory: %v", err)
+	}
+
+	count := 0
+	for _, path := range paths {
+		if !loaded[canonicalPath(path)] {
+			fmt.Printf("⚠ %s is never included from %s\n", path, *root)
+			count++
+		}
+	}
+
+	if count > 0 {
+		fmt.Printf("%d unreferenced config(s)\n", count)
+		exit(1)
+	}
+	fmt.Printf("✓ Every config in %s is included from %s\n", cfg.NginxDir, *root)
+}
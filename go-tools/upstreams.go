@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// UpstreamCheck is the result of dialing one upstream server
+type UpstreamCheck struct {
+	File      string `json:"file"`
+	Upstream  string `json:"upstream"`
+	Server    string `json:"server"`
+	Reachable bool   `json:"reachable"`
+	Latency   string `json:"latency,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// 38. Check Upstreams Functionality - Can we reach the backends at all
+func handleCheckUpstreams(args []string) {
+	fs := flag.NewFlagSet("check-upstreams", flag.ExitOnError)
+	timeout := fs.Duration("timeout", 2*time.Second, "how long to wait for each TCP connect")
+	concurrency := fs.Int("concurrency", 20, "how many servers to dial at once")
+	output := fs.String("output", "text", "output format: text or json")
+	parseFlags(fs, args)
+
+	if *concurrency < 1 {
+		fail(ErrInvalid, "Error: --concurrency must be at least 1")
+	}
+
+	files, errs := scanConfigs()
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	var checks []UpstreamCheck
+	for _, file := range files {
+		if !file.enabled() {
+			continue
+		}
+		for _, upstream := range file.upstreams {
+			for _, server := range upstream.Servers {
+				// Unix sockets aren't reachable over TCP
+				if strings.HasPrefix(server, "unix:") {
+					continue
+				}
+				checks = append(checks, UpstreamCheck{File: file.Filename, Upstream: upstream.Name, Server: server})
+			}
+		}
+	}
+
+	sem := make(chan struct{}, *concurrency)
+	var wg sync.WaitGroup
+	for i := range checks {
+		wg.Add(1)
+		go func(check *UpstreamCheck) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			// Like listen, a server without a port means port 80
+			start := time.Now()
+			conn, err := net.DialTimeout("tcp", normalizeListenAddress(check.Server), *timeout)
+			if err != nil {
+				check.Error = err.Error()
+				return
+			}
+			conn.Close()
+			check.Reachable = true
+			check.Latency = time.Since(start).Round(time.Millisecond).String()
+		}(&checks[i])
+	}
+	wg.Wait()
+
+	unreachable := 0
+	for _, check := range checks {
+		if !check.Reachable {
+			unreachable++
+		}
+	}
+
+	if *output == "json" {
+		if checks == nil {
+			checks = []UpstreamCheck{}
+		}
+		jsonOutput, _ := json.MarshalIndent(checks, "", "  ")
+		fmt.Println(string(jsonOutput))
+	} else {
+		if len(checks) == 0 {
+			fmt.Println("No upstream servers found in enabled configs")
+			return
+		}
+		for _, check := range checks {
+			if check.Reachable {
+				fmt.Printf("✓ %s: %s -> %s (%s)\n", check.File, check.Upstream, check.Server, check.Latency)
+			} else {
+				fmt.Printf("❌ %s: %s -> %s: %s\n", check.File, check.Upstream, check.Server, check.Error)
+			}
+		}
+		fmt.Printf("Summary: %d of %d upstream servers reachable\n", len(checks)-unreachable, len(checks))
+	}
+
+	if unreachable > 0 {
+		exit(1)
+	}
+}
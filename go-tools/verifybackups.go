@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// 41. Verify Backups Functionality - Would every backup restore cleanly
+func handleVerifyBackups(args []string) {
+	fs := flag.NewFlagSet("verify-backups", flag.ExitOnError)
+	output := fs.String("output", "text", "output format: text or json")
+	parseFlags(fs, args)
+
+	paths, err := confPaths(cfg.BackupDir)
+	if err != nil {
+		fail(codeOf(err), "Error reading backup directory: %v", err)
+	}
+
+	results := newResultCollector(*output)
+	for _, path := range paths {
+		filename := confFilename(path)
+
+		testOutput, err := testConfFile(path)
+		if err != nil {
+			message := strings.TrimSpace(string(testOutput))
+			if message == "" {
+				message = err.Error()
+			}
+			fmt.Println(errorLine(ErrNginxTest, fmt.Sprintf("❌ %s would not restore cleanly:\n%s", filename, message)))
+			results.add(filename, "verify", withCode(ErrNginxTest, errors.New(message)))
+			continue
+		}
+		fmt.Printf("✓ %s\n", filename)
+		results.add(filename, "verify", nil)
+	}
+
+	failed := results.failed()
+	fmt.Printf("Summary: %d of %d backups valid\n", len(paths)-failed, len(paths))
+	results.flush()
+	if failed > 0 {
+		exit(1)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// versionDir is where the history of filename is kept
+func versionDir(filename string) string {
+	return filepath.Join(cfg.VersionsDir, filename)
+}
+
+// saveVersion stores content as a new timestamped version of filename and
+// returns the path it was written to
+func saveVersion(filename string, content []byte) (string, error) {
+	dir := versionDir(filename)
+	path := filepath.Join(dir, time.Now().Format("20060102-150405.000000000")+".conf")
+	if simulate {
+		simulated("archive version %s", path)
+		return path, nil
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return path, os.WriteFile(path, content, 0644)
+}
+
+// versionFiles returns the paths of every saved version of filename,
+// oldest first. Version names are timestamps, so they sort in time order.
+func versionFiles(filename string) ([]string, error) {
+	entries, err := os.ReadDir(versionDir(filename))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// ReadDir returns entries sorted by name
+	var paths []string
+	for _, entry := range entries {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == ".conf" {
+			paths = append(paths, filepath.Join(versionDir(filename), entry.Name()))
+		}
+	}
+	return paths, nil
+}
+
+// latestVersion returns the path of the newest saved version of filename,
+// or "" if none exists
+func latestVersion(filename string) (string, error) {
+	paths, err := versionFiles(filename)
+	if err != nil || len(paths) == 0 {
+		return "", err
+	}
+	return paths[len(paths)-1], nil
+}